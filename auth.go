@@ -0,0 +1,211 @@
+// Copyright 2022 eatmoreapple.  All rights reserved.
+// Use of this source code is governed by a GPL style
+// license that can be found in the LICENSE file.
+
+package regia
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	authUserKey      = "regia.auth_user"
+	authPrincipalKey = "regia.auth_principal"
+	defaultAuthRealm = "Restricted"
+)
+
+var (
+	// ErrUnauthorized returned when the request has no valid credentials
+	ErrUnauthorized = NewHttpError(http.StatusUnauthorized, "")
+	// ErrKeyMissing returned when the request of KeyAuth has no key
+	ErrKeyMissing = NewHttpError(http.StatusUnauthorized, "missing key")
+	// ErrKeyInvalid can be returned by the lookup of KeyAuth when the key is invalid
+	ErrKeyInvalid = NewHttpError(http.StatusUnauthorized, "invalid key")
+)
+
+// BasicAuthConfig is the config of BasicAuth
+type BasicAuthConfig struct {
+	// Realm of WWW-Authenticate, default Restricted
+	Realm string
+	// Verifier reports whether the username and password are valid
+	Verifier func(c *Context, username, password string) bool
+	// Skip reports whether the request should not be authenticated
+	Skip func(c *Context) bool
+}
+
+// BasicAuth returns a middleware of HTTP Basic authentication with accounts of username and password
+// The username is stored in Context and can be got by AuthUser
+//
+//	engine.Use(regia.BasicAuth(map[string]string{"admin": "secret"}))
+func BasicAuth(accounts map[string]string) HandleFunc {
+	// compare hashes in constant time regardless of the length of password
+	hashes := make(map[string][sha256.Size]byte, len(accounts))
+	for username, password := range accounts {
+		hashes[username] = sha256.Sum256([]byte(password))
+	}
+	return BasicAuthWithConfig(BasicAuthConfig{
+		Verifier: func(c *Context, username, password string) bool {
+			expected, ok := hashes[username]
+			hash := sha256.Sum256([]byte(password))
+			return subtle.ConstantTimeCompare(expected[:], hash[:]) == 1 && ok
+		},
+	})
+}
+
+// BasicAuthWithConfig returns a BasicAuth middleware with config
+func BasicAuthWithConfig(config BasicAuthConfig) HandleFunc {
+	if config.Verifier == nil {
+		panic("basic auth verifier can not be nil")
+	}
+	if len(config.Realm) == 0 {
+		config.Realm = defaultAuthRealm
+	}
+	challenge := "Basic realm=" + strconv.Quote(config.Realm) + `, charset="UTF-8"`
+	return func(c *Context) {
+		if config.Skip != nil && config.Skip(c) {
+			return
+		}
+		username, password, ok := c.Request.BasicAuth()
+		if !ok || !config.Verifier(c, username, password) {
+			c.ResponseWriter.Header().Set("WWW-Authenticate", challenge)
+			c.AbortWithError(ErrUnauthorized)
+			return
+		}
+		c.SetValue(authUserKey, username)
+	}
+}
+
+// AuthUser returns the username authenticated by BasicAuth
+func AuthUser(c *Context) string {
+	value, _ := c.GetValue(authUserKey)
+	username, _ := value.(string)
+	return username
+}
+
+// KeyAuthConfig is the config of KeyAuth
+type KeyAuthConfig struct {
+	// Lookup returns the principal of key, such as the user or client
+	// Returning error rejects the request with 401 unless the error carries its own status code
+	Lookup func(c *Context, key string) (interface{}, error)
+	// KeyLookup is where the key is found, formatted as source:name
+	// source can be header, query or cookie, default header:Authorization
+	KeyLookup string
+	// Scheme is the auth scheme of key in header, default Bearer for Authorization header
+	Scheme string
+	// Realm of WWW-Authenticate, default Restricted
+	Realm string
+	// Skip reports whether the request should not be authenticated
+	Skip func(c *Context) bool
+}
+
+// KeyAuth returns a middleware which authenticates the request by bearer token or API key
+// The principal returned by lookup is stored in Context and can be got by AuthPrincipal
+//
+//	engine.Use(regia.KeyAuth(func(c *regia.Context, key string) (interface{}, error) {
+//		client, ok := clients[key]
+//		if !ok {
+//			return nil, regia.ErrKeyInvalid
+//		}
+//		return client, nil
+//	}))
+func KeyAuth(lookup func(c *Context, key string) (interface{}, error)) HandleFunc {
+	return KeyAuthWithConfig(KeyAuthConfig{Lookup: lookup})
+}
+
+// KeyAuthWithConfig returns a KeyAuth middleware with config
+func KeyAuthWithConfig(config KeyAuthConfig) HandleFunc {
+	if config.Lookup == nil {
+		panic("key auth lookup can not be nil")
+	}
+	extract, challenge := authExtractor(config.KeyLookup, config.Scheme, config.Realm)
+	return func(c *Context) {
+		if config.Skip != nil && config.Skip(c) {
+			return
+		}
+		key := extract(c)
+		if len(key) == 0 {
+			c.ResponseWriter.Header().Set("WWW-Authenticate", challenge)
+			c.AbortWithError(ErrKeyMissing)
+			return
+		}
+		principal, err := config.Lookup(c, key)
+		if err != nil {
+			code := http.StatusUnauthorized
+			var coder statusCoder
+			if errors.As(err, &coder) {
+				code = coder.StatusCode()
+			} else {
+				err = &HttpError{Code: code, Message: ErrKeyInvalid.Message, Err: err}
+			}
+			if code == http.StatusUnauthorized {
+				c.ResponseWriter.Header().Set("WWW-Authenticate", challenge+`, error="invalid_token"`)
+			}
+			c.AbortWithError(err)
+			return
+		}
+		c.SetValue(authPrincipalKey, principal)
+	}
+}
+
+// AuthPrincipal returns the principal authenticated by KeyAuth
+func AuthPrincipal(c *Context) interface{} {
+	principal, _ := c.GetValue(authPrincipalKey)
+	return principal
+}
+
+// authExtractor returns the func to extract credential from request by lookup like header:Authorization,
+// and the WWW-Authenticate challenge without error
+func authExtractor(lookup, scheme, realm string) (func(c *Context) string, string) {
+	if len(lookup) == 0 {
+		lookup = "header:Authorization"
+	}
+	source, name, ok := strings.Cut(lookup, ":")
+	if !ok || len(name) == 0 {
+		panic("invalid key lookup: " + lookup)
+	}
+	if len(scheme) == 0 && source == "header" && http.CanonicalHeaderKey(name) == "Authorization" {
+		scheme = "Bearer"
+	}
+	if len(realm) == 0 {
+		realm = defaultAuthRealm
+	}
+	challengeScheme := scheme
+	if len(challengeScheme) == 0 {
+		challengeScheme = "ApiKey"
+	}
+	challenge := challengeScheme + " realm=" + strconv.Quote(realm)
+
+	var extract func(c *Context) string
+	switch source {
+	case "header":
+		extract = func(c *Context) string {
+			value := c.Request.Header.Get(name)
+			if len(scheme) == 0 {
+				return value
+			}
+			// scheme is case-insensitive
+			if len(value) > len(scheme) && strings.EqualFold(value[:len(scheme)], scheme) && value[len(scheme)] == ' ' {
+				return strings.TrimSpace(value[len(scheme)+1:])
+			}
+			return ""
+		}
+	case "query":
+		extract = func(c *Context) string { return c.QueryValue(name) }
+	case "cookie":
+		extract = func(c *Context) string {
+			cookie, err := c.Request.Cookie(name)
+			if err != nil {
+				return ""
+			}
+			return cookie.Value
+		}
+	default:
+		panic("invalid key lookup: " + lookup)
+	}
+	return extract, challenge
+}
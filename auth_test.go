@@ -0,0 +1,138 @@
+package regia
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestBasicAuth(t *testing.T) {
+	engine := New()
+	engine.Use(BasicAuth(map[string]string{"admin": "secret"}))
+	engine.GET("/", func(c *Context) { _ = c.String(AuthUser(c)) })
+	_ = engine.init()
+
+	tests := []struct {
+		username, password string
+		code               int
+	}{
+		{"admin", "secret", http.StatusOK},
+		{"admin", "wrong", http.StatusUnauthorized},
+		{"guest", "secret", http.StatusUnauthorized},
+		{"", "", http.StatusUnauthorized},
+	}
+	for _, test := range tests {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
+		if len(test.username) > 0 {
+			request.SetBasicAuth(test.username, test.password)
+		}
+		engine.ServeHTTP(recorder, request)
+		if recorder.Code != test.code {
+			t.Errorf("%s:%s: expected %d, got %d", test.username, test.password, test.code, recorder.Code)
+		}
+		if test.code == http.StatusOK && recorder.Body.String() != "admin" {
+			t.Errorf("expected user admin, got %q", recorder.Body.String())
+		}
+		if challenge := recorder.Header().Get("WWW-Authenticate"); test.code == http.StatusUnauthorized &&
+			challenge != `Basic realm="Restricted", charset="UTF-8"` {
+			t.Errorf("unexpected challenge %q", challenge)
+		}
+	}
+}
+
+func TestKeyAuth(t *testing.T) {
+	lookup := func(c *Context, key string) (interface{}, error) {
+		switch key {
+		case "valid":
+			return "client", nil
+		case "banned":
+			return nil, NewHttpError(http.StatusForbidden, "banned")
+		}
+		return nil, errors.New("unknown key")
+	}
+	engine := New()
+	bearer := NewBluePrint()
+	bearer.Use(KeyAuth(lookup))
+	bearer.GET("/", func(c *Context) { _ = c.String(AuthPrincipal(c).(string)) })
+	engine.Include("", bearer)
+
+	api := NewBluePrint()
+	api.Use(KeyAuthWithConfig(KeyAuthConfig{Lookup: lookup, KeyLookup: "header:X-API-Key"}))
+	api.GET("/key", func(c *Context) { _ = c.String(AuthPrincipal(c).(string)) })
+	engine.Include("/api", api)
+	_ = engine.init()
+
+	tests := []struct {
+		path, header, value string
+		code                int
+		challenge           string
+	}{
+		{"/", "Authorization", "Bearer valid", http.StatusOK, ""},
+		{"/", "Authorization", "bearer valid", http.StatusOK, ""},
+		{"/", "Authorization", "Basic valid", http.StatusUnauthorized, `Bearer realm="Restricted"`},
+		{"/", "Authorization", "Bearer unknown", http.StatusUnauthorized, `Bearer realm="Restricted", error="invalid_token"`},
+		{"/", "Authorization", "Bearer banned", http.StatusForbidden, ""},
+		{"/api/key", "X-API-Key", "valid", http.StatusOK, ""},
+		{"/api/key", "Authorization", "Bearer valid", http.StatusUnauthorized, `ApiKey realm="Restricted"`},
+	}
+	for _, test := range tests {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodGet, test.path, nil)
+		request.Header.Set(test.header, test.value)
+		engine.ServeHTTP(recorder, request)
+		if recorder.Code != test.code || recorder.Header().Get("WWW-Authenticate") != test.challenge {
+			t.Errorf("%s %s: got %d %q", test.path, test.value, recorder.Code, recorder.Header().Get("WWW-Authenticate"))
+		}
+		if test.code == http.StatusOK && recorder.Body.String() != "client" {
+			t.Errorf("expected principal client, got %q", recorder.Body.String())
+		}
+	}
+}
+
+func TestAuthValuesNotLeaked(t *testing.T) {
+	secret := []byte("secret")
+	engine := New()
+	basic := NewBluePrint()
+	basic.Use(BasicAuth(map[string]string{"admin": "secret"}))
+	basic.GET("/", func(c *Context) { _ = c.String(AuthUser(c)) })
+	engine.Include("/basic", basic)
+	key := NewBluePrint()
+	key.Use(KeyAuth(func(c *Context, key string) (interface{}, error) { return "client", nil }))
+	key.GET("/", func(c *Context) { _ = c.String(AuthPrincipal(c).(string)) })
+	engine.Include("/key", key)
+	jwt := NewBluePrint()
+	jwt.Use(JWT(JWTConfig{Key: secret}))
+	jwt.GET("/", func(c *Context) { _ = c.String(GetJWTClaims(c).String("sub")) })
+	engine.Include("/jwt", jwt)
+	engine.GET("/plain", func(c *Context) {
+		if AuthUser(c) != "" || AuthPrincipal(c) != nil || GetJWTClaims(c) != nil {
+			c.SetStatus(http.StatusInternalServerError)
+		}
+	})
+	_ = engine.init()
+
+	token := signJWT(t, JWTHeader{Algorithm: JWTHS256}, Map{"sub": "42", "exp": time.Now().Add(time.Hour).Unix()}, secret)
+	requests := map[string]func(request *http.Request){
+		"/basic/": func(request *http.Request) { request.SetBasicAuth("admin", "secret") },
+		"/key/":   func(request *http.Request) { request.Header.Set("Authorization", "Bearer valid") },
+		"/jwt/":   func(request *http.Request) { request.Header.Set("Authorization", "Bearer "+token) },
+	}
+	for path, authorize := range requests {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodGet, path, nil)
+		authorize(request)
+		engine.ServeHTTP(recorder, request)
+		if recorder.Code != http.StatusOK || len(recorder.Body.String()) == 0 {
+			t.Fatalf("%s: got %d %q", path, recorder.Code, recorder.Body.String())
+		}
+		// the Context is reused from the pool
+		recorder = httptest.NewRecorder()
+		engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/plain", nil))
+		if recorder.Code != http.StatusOK {
+			t.Errorf("%s: authentication of previous request leaked", path)
+		}
+	}
+}
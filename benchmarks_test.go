@@ -40,3 +40,54 @@ func BenchmarkRenderJson(B *testing.B) {
 	router.init()
 	runRequest(B, router, "GET", "/json")
 }
+
+type benchmarkBindItem struct {
+	ID   int    `form:"id"`
+	Name string `form:"name"`
+}
+
+type benchmarkBindQuery struct {
+	Name   string              `form:"name"`
+	Age    int                 `form:"age"`
+	Score  float64             `form:"score"`
+	Admin  bool                `form:"admin"`
+	Tags   []string            `form:"tags"`
+	Page   int                 `form:"page,1"`
+	Items  []benchmarkBindItem `form:"items"`
+	Filter struct {
+		Status string `form:"status"`
+	} `form:"filter"`
+}
+
+func BenchmarkBindQuery(B *testing.B) {
+	router := New()
+	router.GET("/bind", func(c *Context) {
+		var v benchmarkBindQuery
+		_ = c.BindQuery(&v)
+	})
+	router.init()
+	runRequest(B, router, "GET", "/bind?name=regia&age=18&score=99.5&admin=true&tags=a&tags=b")
+}
+
+func BenchmarkBindQueryNested(B *testing.B) {
+	router := New()
+	router.GET("/bind", func(c *Context) {
+		var v benchmarkBindQuery
+		_ = c.BindQuery(&v)
+	})
+	router.init()
+	runRequest(B, router, "GET", "/bind?name=regia&items[0].id=1&items[0].name=a&items[1].id=2&filter.status=on")
+}
+
+func BenchmarkBindURI(B *testing.B) {
+	router := New()
+	router.GET("/bind/:name/:id", func(c *Context) {
+		var v struct {
+			Name string `uri:"name"`
+			ID   int    `uri:"id"`
+		}
+		_ = c.BindURI(&v)
+	})
+	router.init()
+	runRequest(B, router, "GET", "/bind/regia/1")
+}
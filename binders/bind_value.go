@@ -14,6 +14,12 @@ import (
 )
 
 func bind(field reflect.Value, formValues []string) error {
+	if isConvertible(field.Type()) {
+		if len(formValues) > 0 {
+			return bindSingle(field, formValues[0])
+		}
+		return nil
+	}
 	switch field.Kind() {
 	case reflect.Array:
 		return bindArray(field, formValues)
@@ -96,6 +102,9 @@ func bindSlice(field reflect.Value, formValues []string) error {
 }
 
 func bindSingle(field reflect.Value, formValue string) error {
+	if convert, ok := converterFor(field.Type()); ok {
+		return convert(field, formValue)
+	}
 	switch field.Kind() {
 	case reflect.String:
 		field.SetString(formValue)
@@ -130,7 +139,15 @@ func bindSingle(field reflect.Value, formValue string) error {
 	case reflect.Map:
 		return json.Unmarshal([]byte(formValue), field.Addr().Interface())
 	case reflect.Ptr:
-		return json.Unmarshal([]byte(formValue), field.Addr().Interface())
+		// leave pointer nil if there is no value, except pointer of string
+		if formValue == "" && field.Type().Elem().Kind() != reflect.String {
+			return nil
+		}
+		elem := reflect.New(field.Type().Elem())
+		if err := bindSingle(elem.Elem(), formValue); err != nil {
+			return err
+		}
+		field.Set(elem)
 	default:
 		return errors.New("unknown type got")
 	}
@@ -5,58 +5,143 @@
 package binders
 
 import (
-	"github.com/eatmoreapple/regia/internal"
+	"bytes"
+	"github.com/eatmoreapple/regia/serializers"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"net/url"
+	"reflect"
 )
 
 type Binder interface {
 	Bind(request *http.Request, v interface{}) error
 }
 
-type QueryBinder struct{}
+type QueryBinder struct {
+	// Config uses DefaultConfig if nil
+	Config *Config
+	// Presence records the fields found if not nil
+	Presence *Presence
+}
 
-func (QueryBinder) Bind(request *http.Request, v interface{}) error {
-	binder := URLValueBinder{TagName: formTag, BindTagName: bindTag}
+func (q QueryBinder) Bind(request *http.Request, v interface{}) error {
+	config := configOrDefault(q.Config)
+	binder := config.URLValueBinder(config.FormTag, SourceQuery)
+	binder.Presence = q.Presence
 	return binder.BindForm(request.URL.Query(), v)
 }
 
-type FormBinder struct{}
+type FormBinder struct {
+	// Config uses DefaultConfig if nil
+	Config *Config
+	// Presence records the fields found if not nil
+	Presence *Presence
+}
 
-func (FormBinder) Bind(request *http.Request, v interface{}) error {
-	binder := URLValueBinder{TagName: formTag, BindTagName: bindTag}
+func (f FormBinder) Bind(request *http.Request, v interface{}) error {
+	config := configOrDefault(f.Config)
+	binder := config.URLValueBinder(config.FormTag, SourceForm)
+	binder.Presence = f.Presence
 	return binder.BindForm(request.Form, v)
 }
 
-type MultipartFormBodyBinder struct{}
+type MultipartFormBodyBinder struct {
+	// Config uses DefaultConfig if nil
+	Config *Config
+	// Presence records the fields found if not nil
+	Presence *Presence
+}
 
-func (MultipartFormBodyBinder) Bind(request *http.Request, v interface{}) error {
-	urlValueBinder := URLValueBinder{TagName: formTag, BindTagName: bindTag}
-	binder := HttpMultipartFormBinder{URLValueBinder: urlValueBinder, FieldTag: fileTag}
+func (m MultipartFormBodyBinder) Bind(request *http.Request, v interface{}) error {
+	config := configOrDefault(m.Config)
+	urlValueBinder := config.URLValueBinder(config.FormTag, SourceForm)
+	urlValueBinder.Presence = m.Presence
+	binder := HttpMultipartFormBinder{URLValueBinder: urlValueBinder, FieldTag: config.FileTag}
 	return binder.BindMultipartForm(request.MultipartForm, v)
 }
 
 type JsonBodyBinder struct {
-	Serializer internal.Serializer
+	Serializer serializers.Serializer
+	// Presence records the fields found in json object if not nil
+	Presence *Presence
 }
 
 func (j JsonBodyBinder) Bind(request *http.Request, v interface{}) error {
-	return j.Serializer.Decode(request.Body, v)
+	if j.Presence == nil {
+		return j.Serializer.Decode(request.Body, v)
+	}
+	data, err := io.ReadAll(request.Body)
+	if err != nil {
+		return err
+	}
+	if err = j.Serializer.Decode(bytes.NewReader(data), v); err != nil {
+		return err
+	}
+	j.Presence.addJSON(data, reflect.TypeOf(v))
+	return nil
 }
 
 type XmlBodyBinder struct {
-	Serializer internal.Serializer
+	Serializer serializers.Serializer
 }
 
 func (j XmlBodyBinder) Bind(request *http.Request, v interface{}) error {
 	return j.Serializer.Decode(request.Body, v)
 }
 
-type HeaderBinder struct{}
+type YamlBodyBinder struct {
+	Serializer serializers.Serializer
+}
+
+func (y YamlBodyBinder) Bind(request *http.Request, v interface{}) error {
+	return y.Serializer.Decode(request.Body, v)
+}
+
+type TomlBodyBinder struct {
+	Serializer serializers.Serializer
+}
+
+func (t TomlBodyBinder) Bind(request *http.Request, v interface{}) error {
+	return t.Serializer.Decode(request.Body, v)
+}
+
+type MsgPackBodyBinder struct {
+	Serializer serializers.Serializer
+}
+
+func (m MsgPackBodyBinder) Bind(request *http.Request, v interface{}) error {
+	return m.Serializer.Decode(request.Body, v)
+}
+
+type CborBodyBinder struct {
+	Serializer serializers.Serializer
+}
+
+func (c CborBodyBinder) Bind(request *http.Request, v interface{}) error {
+	return c.Serializer.Decode(request.Body, v)
+}
+
+type ProtobufBodyBinder struct {
+	Serializer serializers.Serializer
+}
+
+func (p ProtobufBodyBinder) Bind(request *http.Request, v interface{}) error {
+	return p.Serializer.Decode(request.Body, v)
+}
+
+type HeaderBinder struct {
+	// Config uses DefaultConfig if nil
+	Config *Config
+	// Presence records the fields found if not nil
+	Presence *Presence
+}
 
 func (h HeaderBinder) Bind(request *http.Request, v interface{}) error {
+	config := configOrDefault(h.Config)
 	values := url.Values(request.Header)
-	binder := URLValueBinder{TagName: headerTag, BindTagName: bindTag}
+	binder := config.URLValueBinder(config.HeaderTag, SourceHeader)
+	binder.Presence = h.Presence
 	return binder.BindForm(values, v)
 }
 
@@ -64,9 +149,109 @@ type URIParamContextKey struct{}
 
 type URIBinder struct {
 	Values url.Values
+	// Config uses DefaultConfig if nil
+	Config *Config
+	// Presence records the fields found if not nil
+	Presence *Presence
 }
 
 func (u URIBinder) Bind(request *http.Request, v interface{}) error {
-	binder := URLValueBinder{TagName: uriTag, BindTagName: bindTag}
+	config := configOrDefault(u.Config)
+	binder := config.URLValueBinder(config.URITag, SourceURI)
+	binder.Presence = u.Presence
 	return binder.BindForm(u.Values, v)
 }
+
+type CookieBinder struct {
+	// Config uses DefaultConfig if nil
+	Config *Config
+	// Presence records the fields found if not nil
+	Presence *Presence
+}
+
+func (c CookieBinder) Bind(request *http.Request, v interface{}) error {
+	config := configOrDefault(c.Config)
+	binder := config.URLValueBinder(config.CookieTag, SourceCookie)
+	binder.Presence = c.Presence
+	return binder.BindForm(cookieValues(request), v)
+}
+
+// cookieValues returns the cookies of request as url.Values
+func cookieValues(request *http.Request) url.Values {
+	values := make(url.Values)
+	for _, cookie := range request.Cookies() {
+		values.Add(cookie.Name, cookie.Value)
+	}
+	return values
+}
+
+// MultipartStreamBinder reads multipart body part by part without buffering files
+// Values of form fields are bound after all parts have been read
+// File parts are passed to Handle in order, which must consume them before returning
+type MultipartStreamBinder struct {
+	// Handle is called with every file part
+	Handle func(part *multipart.Part) error
+	// MaxMemory limits the total size of form values, 0 means no limit
+	MaxMemory int64
+	// Config uses DefaultConfig if nil
+	Config *Config
+	// Presence records the fields found if not nil
+	Presence *Presence
+}
+
+func (m MultipartStreamBinder) Bind(request *http.Request, v interface{}) error {
+	reader, err := request.MultipartReader()
+	if err != nil {
+		return err
+	}
+	values := make(url.Values)
+	remaining := m.MaxMemory
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if len(part.FileName()) == 0 {
+			value, err := readPartValue(part, &remaining, m.MaxMemory > 0)
+			_ = part.Close()
+			if err != nil {
+				return err
+			}
+			values.Add(part.FormName(), value)
+			continue
+		}
+		if m.Handle != nil {
+			if err = m.Handle(part); err != nil {
+				_ = part.Close()
+				return err
+			}
+		}
+		_ = part.Close()
+	}
+	config := configOrDefault(m.Config)
+	binder := config.URLValueBinder(config.FormTag, SourceForm)
+	binder.Presence = m.Presence
+	return binder.BindForm(values, v)
+}
+
+// readPartValue reads value of form field with the remaining memory
+func readPartValue(part *multipart.Part, remaining *int64, limited bool) (string, error) {
+	var reader io.Reader = part
+	if limited {
+		reader = io.LimitReader(part, *remaining+1)
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return "", err
+	}
+	if limited {
+		*remaining -= int64(len(data))
+		if *remaining < 0 {
+			return "", multipart.ErrMessageTooLarge
+		}
+	}
+	return string(data), nil
+}
@@ -0,0 +1,90 @@
+// Copyright 2022 eatmoreapple.  All rights reserved.
+// Use of this source code is governed by a GPL style
+// license that can be found in the LICENSE file.
+
+package binders
+
+import "fmt"
+
+// Config is the configuration shared by the binders of url values
+// such as QueryBinder, FormBinder, MultipartFormBodyBinder, HeaderBinder, CookieBinder and URIBinder
+type Config struct {
+	// Tag names used to look up the keys of fields
+	FormTag   string
+	HeaderTag string
+	URITag    string
+	FileTag   string
+	CookieTag string
+	// QueryTag is used by MultiSourceBinder only,
+	// QueryBinder uses FormTag for compatibility
+	QueryTag string
+	// BindTag is the tag name of custom BindMethod
+	BindTag string
+
+	// BindMethods are called by fields with BindTag, such as `bind:"unix"`
+	BindMethods map[string]BindMethod
+
+	// DefaultOnEmpty binds the default value of tag, such as `form:"page,1"`,
+	// even if the key exists but all of its values are empty
+	DefaultOnEmpty bool
+	// DisableDefault ignores the default value of tag
+	DisableDefault bool
+}
+
+// DefaultConfig returns Config with default tag names
+func DefaultConfig() *Config {
+	return &Config{
+		FormTag:   formTag,
+		HeaderTag: headerTag,
+		URITag:    uriTag,
+		FileTag:   fileTag,
+		CookieTag: cookieTag,
+		QueryTag:  queryTag,
+		BindTag:   bindTag,
+	}
+}
+
+// defaultConfig is used by binders without Config
+var defaultConfig = DefaultConfig()
+
+func configOrDefault(config *Config) *Config {
+	if config == nil {
+		return defaultConfig
+	}
+	return config
+}
+
+// AddBindMethod add BindMethod named name to Config
+func (c *Config) AddBindMethod(name string, method BindMethod) error {
+	if _, exist := c.BindMethods[name]; exist {
+		return fmt.Errorf("%s already exist", name)
+	}
+	if c.BindMethods == nil {
+		c.BindMethods = make(map[string]BindMethod)
+	}
+	c.BindMethods[name] = method
+	return nil
+}
+
+// Clone returns a copy of Config
+// BindMethods added to the copy will not affect the original one
+func (c *Config) Clone() *Config {
+	clone := *c
+	clone.BindMethods = make(map[string]BindMethod, len(c.BindMethods))
+	for name, method := range c.BindMethods {
+		clone.BindMethods[name] = method
+	}
+	return &clone
+}
+
+// URLValueBinder returns URLValueBinder with given tag name and source
+func (c *Config) URLValueBinder(tagName, source string) URLValueBinder {
+	return URLValueBinder{
+		TagName:        tagName,
+		BindTagName:    c.BindTag,
+		BindMethods:    c.BindMethods,
+		Source:         source,
+		DefaultOnEmpty: c.DefaultOnEmpty,
+		DisableDefault: c.DisableDefault,
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright 2022 eatmoreapple.  All rights reserved.
+// Use of this source code is governed by a GPL style
+// license that can be found in the LICENSE file.
+
+package binders
+
+import (
+	"encoding"
+	"errors"
+	"net"
+	"net/url"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// TimeLayouts are tried in order to bind time.Time
+// Replace it before binding if you need other layouts
+var TimeLayouts = []string{
+	time.RFC3339Nano,
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// Scanner is implemented by types which could scan themselves from string
+// It has the same signature as sql.Scanner
+type Scanner interface {
+	Scan(src interface{}) error
+}
+
+// converter binds string into field of its type
+type converter func(field reflect.Value, formValue string) error
+
+// converters caches converter by reflect.Type
+var converters sync.Map
+
+var (
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	scannerType         = reflect.TypeOf((*Scanner)(nil)).Elem()
+)
+
+// RegisterConverter registers a global converter for type T
+// It takes precedence over encoding.TextUnmarshaler, Scanner and builtin conversions
+// Converters should be registered before binding, such as in init function
+func RegisterConverter[T any](fn func(formValue string) (T, error)) {
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+	converters.Store(typ, converter(func(field reflect.Value, formValue string) error {
+		v, err := fn(formValue)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(&v).Elem())
+		return nil
+	}))
+}
+
+func init() {
+	RegisterConverter(parseTime)
+	RegisterConverter(time.ParseDuration)
+	RegisterConverter(parseIP)
+	RegisterConverter(parseURL)
+}
+
+func parseTime(formValue string) (time.Time, error) {
+	var err error
+	for _, layout := range TimeLayouts {
+		var t time.Time
+		if t, err = time.Parse(layout, formValue); err == nil {
+			return t, nil
+		}
+	}
+	if err == nil {
+		err = errors.New("no time layout found")
+	}
+	return time.Time{}, err
+}
+
+func parseIP(formValue string) (net.IP, error) {
+	ip := net.ParseIP(formValue)
+	if ip == nil {
+		return nil, errors.New("invalid ip address")
+	}
+	return ip, nil
+}
+
+func parseURL(formValue string) (url.URL, error) {
+	u, err := url.Parse(formValue)
+	if err != nil {
+		return url.URL{}, err
+	}
+	return *u, nil
+}
+
+// converterFor returns converter of given type
+// Registered converter, Optional, encoding.TextUnmarshaler and Scanner will be tried in order
+func converterFor(typ reflect.Type) (converter, bool) {
+	if c, ok := converters.Load(typ); ok {
+		return c.(converter), true
+	}
+	if isOptional(typ) {
+		bindOptional := optionalBindMethod(typ)
+		return func(field reflect.Value, formValue string) error {
+			return bindOptional(field, []string{formValue})
+		}, true
+	}
+	ptr := reflect.PtrTo(typ)
+	if ptr.Implements(textUnmarshalerType) {
+		return func(field reflect.Value, formValue string) error {
+			return field.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(formValue))
+		}, true
+	}
+	if ptr.Implements(scannerType) {
+		return func(field reflect.Value, formValue string) error {
+			return field.Addr().Interface().(Scanner).Scan(formValue)
+		}, true
+	}
+	return nil, false
+}
+
+// isConvertible reports whether the type should be bound from a single string
+// even though it is a struct or slice, such as time.Time and net.IP
+func isConvertible(typ reflect.Type) bool {
+	_, ok := converterFor(typ)
+	return ok
+}
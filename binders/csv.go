@@ -0,0 +1,102 @@
+// Copyright 2022 eatmoreapple.  All rights reserved.
+// Use of this source code is governed by a GPL style
+// license that can be found in the LICENSE file.
+
+package binders
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/eatmoreapple/regia/internal"
+)
+
+// CSVBodyBinder binds csv rows of request body into a slice of structs
+// The first row must be the header, columns are matched by `csv` struct tags
+type CSVBodyBinder struct {
+	// Comma is the field delimiter, default is ','
+	Comma rune
+}
+
+func (c CSVBodyBinder) Bind(request *http.Request, v interface{}) error {
+	value := reflect.ValueOf(v)
+	if value.Kind() != reflect.Ptr || value.Elem().Kind() != reflect.Slice {
+		return errors.New("pointer of slice type required")
+	}
+	slice := value.Elem()
+	elemType := slice.Type().Elem()
+	isPtr := elemType.Kind() == reflect.Ptr
+	if isPtr {
+		elemType = elemType.Elem()
+	}
+	if elemType.Kind() != reflect.Struct {
+		return errors.New("slice of struct required")
+	}
+
+	reader := csv.NewReader(request.Body)
+	if c.Comma != 0 {
+		reader.Comma = c.Comma
+	}
+	reader.ReuseRecord = true
+
+	record, err := reader.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil
+		}
+		return err
+	}
+	// record will be reused by reader
+	header := append([]string(nil), record...)
+	// match header with struct fields
+	indexes := make(map[string][]int)
+	for _, column := range internal.CSVColumns(elemType) {
+		indexes[column.Name] = column.Index
+	}
+	fields := make([][]int, len(header))
+	for i, name := range header {
+		// trim utf-8 bom of the first column
+		if i == 0 && strings.HasPrefix(name, "\xEF\xBB\xBF") {
+			name = name[3:]
+			header[i] = name
+		}
+		fields[i] = indexes[name]
+	}
+
+	for row := 1; ; row++ {
+		record, err = reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		elem := reflect.New(elemType)
+		for i, formValue := range record {
+			if i >= len(fields) || fields[i] == nil {
+				continue
+			}
+			if err = bindCSVValue(elem.Elem().FieldByIndex(fields[i]), formValue); err != nil {
+				return fmt.Errorf("csv row %d column %q: %w", row, header[i], err)
+			}
+		}
+		if !isPtr {
+			elem = elem.Elem()
+		}
+		slice.Set(reflect.Append(slice, elem))
+	}
+	return nil
+}
+
+// bindCSVValue leaves the field as zero value if the cell is empty
+func bindCSVValue(field reflect.Value, formValue string) error {
+	if len(formValue) == 0 {
+		return nil
+	}
+	return bindSingle(field, formValue)
+}
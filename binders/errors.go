@@ -0,0 +1,118 @@
+// Copyright 2022 eatmoreapple.  All rights reserved.
+// Use of this source code is governed by a GPL style
+// license that can be found in the LICENSE file.
+
+package binders
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Sources of the binding values
+const (
+	SourceQuery  = "query"
+	SourceForm   = "form"
+	SourceHeader = "header"
+	SourceURI    = "uri"
+	SourceCookie = "cookie"
+	SourceFile   = "file"
+)
+
+// FieldError describes a field failed to bind
+type FieldError struct {
+	// Source is where the value comes from, such as query, form, header, cookie and uri
+	Source string `json:"source"`
+	// Key is the key of value in the source
+	Key string `json:"key"`
+	// Value is the raw value
+	Value string `json:"value"`
+	// Type is the expected type of field
+	Type    string `json:"type"`
+	Message string `json:"message"`
+	Err     error  `json:"-"`
+}
+
+func newFieldError(source, key string, values []string, typ reflect.Type, err error) *FieldError {
+	fieldErr := &FieldError{
+		Source: source,
+		Key:    key,
+		Value:  strings.Join(values, ","),
+		Type:   typ.String(),
+		Err:    err,
+	}
+	fieldErr.Message = fmt.Sprintf("cannot bind %s %q with %q as %s: %s",
+		fieldErr.Source, fieldErr.Key, fieldErr.Value, fieldErr.Type, cause(err))
+	return fieldErr
+}
+
+// cause drops the function name of strconv.NumError
+func cause(err error) string {
+	var numErr *strconv.NumError
+	if errors.As(err, &numErr) {
+		return numErr.Err.Error()
+	}
+	return err.Error()
+}
+
+func (f *FieldError) Error() string {
+	return f.Message
+}
+
+func (f *FieldError) Unwrap() error {
+	return f.Err
+}
+
+// BindError lists every field failed to bind
+type BindError struct {
+	Errors []*FieldError
+}
+
+func (b *BindError) Error() string {
+	messages := make([]string, len(b.Errors))
+	for i, err := range b.Errors {
+		messages[i] = err.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// StatusCode returns 400 which is used to reply the request
+func (b *BindError) StatusCode() int {
+	return http.StatusBadRequest
+}
+
+// Detail returns the failed fields
+func (b *BindError) Detail() interface{} {
+	return b.Errors
+}
+
+// add appends FieldError to BindError
+func (b *BindError) add(err *FieldError) {
+	b.Errors = append(b.Errors, err)
+}
+
+// merge appends the FieldErrors of err if it is BindError
+// other errors will be returned
+func (b *BindError) merge(err error) error {
+	if err == nil {
+		return nil
+	}
+	var bindErr *BindError
+	if errors.As(err, &bindErr) {
+		b.Errors = append(b.Errors, bindErr.Errors...)
+		return nil
+	}
+	return err
+}
+
+// err returns nil if there is no FieldError
+func (b *BindError) err() error {
+	if len(b.Errors) == 0 {
+		return nil
+	}
+	return b
+}
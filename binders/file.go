@@ -0,0 +1,145 @@
+// Copyright 2022 eatmoreapple.  All rights reserved.
+// Use of this source code is governed by a GPL style
+// license that can be found in the LICENSE file.
+
+package binders
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// sniffLen is the max length of bytes used by http.DetectContentType
+const sniffLen = 512
+
+var (
+	// ErrFileTooLarge returned when upload file exceeds the max size of file tag
+	ErrFileTooLarge = errors.New("file too large")
+	// ErrFileType returned when the sniffed type of upload file is not allowed by file tag
+	ErrFileType = errors.New("file type not allowed")
+)
+
+// fileConstraint is the constraint of upload file declared in file tag
+//
+//	Avatar *multipart.FileHeader `file:"avatar,max=5MB,types=image/png|image/jpeg"`
+//
+// The type of file is sniffed from its content instead of the Content-Type given by client
+// Types like image/* are supported
+type fileConstraint struct {
+	maxSize int64
+	types   []string
+}
+
+// parseFileTag parses the key and constraint of file tag
+func parseFileTag(tag string) (string, *fileConstraint, error) {
+	items := strings.Split(tag, ",")
+	var constraint *fileConstraint
+	for _, item := range items[1:] {
+		item = strings.TrimSpace(item)
+		if len(item) == 0 {
+			continue
+		}
+		if constraint == nil {
+			constraint = &fileConstraint{}
+		}
+		name, param, _ := strings.Cut(item, "=")
+		switch name {
+		case "max":
+			size, err := parseSize(param)
+			if err != nil {
+				return "", nil, err
+			}
+			constraint.maxSize = size
+		case "types":
+			for _, typ := range strings.Split(param, "|") {
+				if typ = strings.ToLower(strings.TrimSpace(typ)); len(typ) > 0 {
+					constraint.types = append(constraint.types, typ)
+				}
+			}
+		default:
+			return "", nil, fmt.Errorf("unknown file constraint %q", name)
+		}
+	}
+	return strings.TrimSpace(items[0]), constraint, nil
+}
+
+// parseSize parses size like 512, 512B, 10KB, 5MB and 1GB
+func parseSize(size string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(size))
+	unit := int64(1)
+	for _, u := range []struct {
+		suffix string
+		size   int64
+	}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1}} {
+		if strings.HasSuffix(s, u.suffix) {
+			s, unit = strings.TrimSpace(strings.TrimSuffix(s, u.suffix)), u.size
+			break
+		}
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid file size %q", size)
+	}
+	return n * unit, nil
+}
+
+// check checks the size and sniffed type of upload files
+func (f *fileConstraint) check(files []*multipart.FileHeader) error {
+	for _, file := range files {
+		if file == nil {
+			continue
+		}
+		if f.maxSize > 0 && file.Size > f.maxSize {
+			return fmt.Errorf("%w: %s exceeds %d bytes", ErrFileTooLarge, file.Filename, f.maxSize)
+		}
+		if len(f.types) == 0 {
+			continue
+		}
+		typ, err := SniffFileType(file)
+		if err != nil {
+			return err
+		}
+		if !f.allowed(typ) {
+			return fmt.Errorf("%w: %s is %s", ErrFileType, file.Filename, typ)
+		}
+	}
+	return nil
+}
+
+func (f *fileConstraint) allowed(typ string) bool {
+	for _, allowed := range f.types {
+		if allowed == typ {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(allowed, "/*"); ok && strings.HasPrefix(typ, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// SniffFileType detects the media type of upload file by its first 512 bytes
+// The parameters such as charset are dropped
+func SniffFileType(file *multipart.FileHeader) (string, error) {
+	src, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+	buf := make([]byte, sniffLen)
+	n, err := io.ReadFull(src, buf)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	typ := http.DetectContentType(buf[:n])
+	if mediaType, _, err := mime.ParseMediaType(typ); err == nil {
+		typ = mediaType
+	}
+	return typ, nil
+}
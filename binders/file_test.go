@@ -0,0 +1,102 @@
+package binders
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// pngHeader is the magic bytes of png
+var pngHeader = []byte("\x89PNG\r\n\x1a\n")
+
+func newMultipartRequest(t *testing.T, values map[string]string, files map[string][]byte) *http.Request {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for name, value := range values {
+		if err := writer.WriteField(name, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, content := range files {
+		part, err := writer.CreateFormFile(name, name+".bin")
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = part.Write(content)
+	}
+	_ = writer.Close()
+	request := httptest.NewRequest(http.MethodPost, "/", &body)
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+	return request
+}
+
+func TestMultipartFileConstraint(t *testing.T) {
+	var v struct {
+		Avatar *multipart.FileHeader   `file:"avatar,max=1KB,types=image/png|image/jpeg"`
+		Doc    *multipart.FileHeader   `file:"doc,types=image/*"`
+		Large  []*multipart.FileHeader `file:"large,max=16B"`
+	}
+	request := newMultipartRequest(t, nil, map[string][]byte{
+		"avatar": append(pngHeader, "data"...),
+		"doc":    []byte("plain text"),
+		"large":  bytes.Repeat([]byte("x"), 17),
+	})
+	if err := request.ParseMultipartForm(1 << 20); err != nil {
+		t.Fatal(err)
+	}
+	err := MultipartFormBodyBinder{}.Bind(request, &v)
+	var bindErr *BindError
+	if !errors.As(err, &bindErr) || len(bindErr.Errors) != 2 {
+		t.Fatalf("expected 2 field errors, got %v", err)
+	}
+	if !errors.Is(bindErr.Errors[0], ErrFileType) || !errors.Is(bindErr.Errors[1], ErrFileTooLarge) {
+		t.Errorf("unexpected field errors %v", bindErr.Errors)
+	}
+	if v.Avatar == nil || v.Doc != nil || v.Large != nil {
+		t.Errorf("unexpected result %+v", v)
+	}
+}
+
+func TestParseFileTag(t *testing.T) {
+	key, constraint, err := parseFileTag("avatar, max=5MB, types=image/png|IMAGE/JPEG")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key != "avatar" || constraint.maxSize != 5<<20 || len(constraint.types) != 2 || constraint.types[1] != "image/jpeg" {
+		t.Errorf("unexpected result %q %+v", key, constraint)
+	}
+	for _, tag := range []string{"a,max=5XB", "a,max=-1", "a,size=1"} {
+		if _, _, err = parseFileTag(tag); err == nil {
+			t.Errorf("expected error of %q", tag)
+		}
+	}
+}
+
+func TestMultipartStreamBinder(t *testing.T) {
+	var v struct {
+		Name string `form:"name"`
+	}
+	request := newMultipartRequest(t, map[string]string{"name": "bob"}, map[string][]byte{"file": []byte("content")})
+	var received []byte
+	binder := MultipartStreamBinder{Handle: func(part *multipart.Part) error {
+		var err error
+		received, err = io.ReadAll(part)
+		return err
+	}}
+	if err := binder.Bind(request, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Name != "bob" || string(received) != "content" {
+		t.Errorf("unexpected result %+v %q", v, received)
+	}
+
+	request = newMultipartRequest(t, map[string]string{"name": "bob"}, nil)
+	binder = MultipartStreamBinder{MaxMemory: 2}
+	if err := binder.Bind(request, &v); !errors.Is(err, multipart.ErrMessageTooLarge) {
+		t.Errorf("expected ErrMessageTooLarge, got %v", err)
+	}
+}
@@ -10,7 +10,6 @@ import (
 	"mime/multipart"
 	"net/url"
 	"reflect"
-	"strings"
 )
 
 const (
@@ -20,6 +19,8 @@ const (
 	fileTag   = "file"
 	headerTag = "header"
 	uriTag    = "uri"
+	queryTag  = "query"
+	cookieTag = "cookie"
 )
 
 // EmptyMultipartFormError may be used outside
@@ -39,76 +40,36 @@ type URLValueBinder struct {
 	TagName     string
 	BindTagName string
 	BindMethods map[string]BindMethod
+	// Source is where the values come from, it will be reported by FieldError
+	Source string
+	// DefaultOnEmpty binds the default value of tag if all values of the key are empty
+	DefaultOnEmpty bool
+	// DisableDefault ignores the default value of tag
+	DisableDefault bool
+	// Overlay binds only the fields tagged with TagName explicitly,
+	// and the default value of tag will not override the non-zero value
+	// It is used to bind values of multiple sources into one struct
+	Overlay bool
+	// Presence records the fields found if not nil
+	Presence *Presence
 }
 
+// BindForm binds url.Values into the struct pointed by v
+// Nested fields can be addressed with dot and bracket notation, such as
+//
+//	user.name, items[0].id, tags[], meta[key]
+//
+// Fields of anonymous embedded struct will be promoted
 func (f URLValueBinder) BindForm(form url.Values, v interface{}) error {
 	value := reflect.ValueOf(v)
-	if value.Kind() != reflect.Ptr {
-		return errors.New("pointer type required")
+	if value.Kind() != reflect.Ptr || value.Elem().Kind() != reflect.Struct {
+		return errors.New("pointer of struct type required")
 	}
-	value = value.Elem()
-	t := reflect.TypeOf(v).Elem()
-	for i := 0; i < value.NumField(); i++ {
-		field := t.Field(i)
-
-		tag, exist := field.Tag.Lookup(f.TagName)
-		if !exist {
-			// set default tag value
-			tag = field.Name
-		}
-
-		tags := strings.Split(tag, ",")
-
-		var (
-			formKey      string
-			defFormValue []string
-		)
-
-		if len(tags) == 1 {
-			formKey = tags[0]
-		} else {
-			formKey = tags[0]
-			defFormValue = tags[1:]
-		}
-
-		if formKey == pass {
-			continue
-		}
-
-		if formValue, exist := form[formKey]; exist {
-			// if we need custom bind by self
-			customBindTag, found := field.Tag.Lookup(f.BindTagName)
-			if found {
-				// custom bind by self
-				if method := f.BindMethods[customBindTag]; method != nil {
-					if err := method(value.Field(i), formValue); err != nil {
-						return err
-					}
-				} else {
-					return errors.New("no method named " + customBindTag)
-				}
-				continue
-			}
-			if err := bind(value.Field(i), formValue); err != nil {
-				// try to bind default value
-				if len(defFormValue) > 0 {
-					if err = bind(value.Field(i), defFormValue); err != nil {
-						return err
-					}
-				}
-				return err
-			}
-		} else {
-			// try to bind default value
-			if len(defFormValue) > 0 {
-				if err := bind(value.Field(i), defFormValue); err != nil {
-					return err
-				}
-			}
-		}
+	w := &formWalker{binder: &f, form: form, bindErr: &BindError{}, presence: f.Presence}
+	if err := w.bind(value.Elem()); err != nil {
+		return err
 	}
-
-	return nil
+	return w.bindErr.err()
 }
 
 func (f *URLValueBinder) AddBindMethod(name string, method BindMethod) error {
@@ -132,84 +93,30 @@ func (m *HttpMultipartFormBinder) BindMultipartForm(form *multipart.Form, v inte
 		return EmptyMultipartFormError
 	}
 	value := reflect.ValueOf(v)
-	if value.Kind() != reflect.Ptr {
-		return errors.New("pointer type required")
+	if value.Kind() != reflect.Ptr || value.Elem().Kind() != reflect.Struct {
+		return errors.New("pointer of struct type required")
 	}
-	value = value.Elem()
-	t := reflect.TypeOf(v).Elem()
-	for i := 0; i < value.NumField(); i++ {
-		field := t.Field(i)
-
-		tag, exist := field.Tag.Lookup(m.TagName)
-		if !exist {
-			// set default tag value
-			tag = field.Name
-		}
-
-		tags := strings.Split(tag, ",")
-
-		var (
-			formKey      string
-			defFormValue []string
-		)
-
-		if len(tags) == 1 {
-			formKey = tags[0]
-		} else {
-			formKey = tags[0]
-			defFormValue = tags[1:]
-		}
-
-		if formKey == pass {
-			continue
-		}
-
-		if formValue, exist := form.Value[formKey]; exist {
-			// if we need custom bind by self
-			customBindTag, found := field.Tag.Lookup(m.BindTagName)
-			if found {
-				// custom bind by self
-				if method := m.BindMethods[customBindTag]; method != nil {
-					if err := method(value.Field(i), formValue); err != nil {
-						return err
-					}
-				}
-				continue
-			}
-			if err := bind(value.Field(i), formValue); err != nil {
-				// try to bind default value
-				if len(defFormValue) > 0 {
-					if err = bind(value.Field(i), defFormValue); err != nil {
-						return err
-					}
-				}
-				return err
-			}
-			continue
-		} else {
-			// try to bind default value
-			if len(defFormValue) > 0 {
-				if err := bind(value.Field(i), defFormValue); err != nil {
-					return err
-				}
-			}
-		}
+	w := &formWalker{
+		binder:   &m.URLValueBinder,
+		form:     form.Value,
+		files:    form.File,
+		fileTag:  m.FieldTag,
+		bindErr:  &BindError{},
+		presence: m.Presence,
+	}
+	if err := w.bind(value.Elem()); err != nil {
+		return err
+	}
+	return w.bindErr.err()
+}
 
-		switch value.Field(i).Interface().(type) {
-		case *multipart.FileHeader, []*multipart.FileHeader:
-			fileTag, exist := field.Tag.Lookup(m.FieldTag)
-			if !exist {
-				fileTag = field.Name
-			}
-			if fileTag == pass {
-				break
-			}
-			if files, exist := form.File[fileTag]; exist {
-				if err := bindFile(value.Field(i), files); err != nil {
-					return err
-				}
-			}
+// fileNames returns names of the upload files
+func fileNames(files []*multipart.FileHeader) []string {
+	names := make([]string, 0, len(files))
+	for _, file := range files {
+		if file != nil {
+			names = append(names, file.Filename)
 		}
 	}
-	return nil
+	return names
 }
@@ -0,0 +1,216 @@
+package binders
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestURLValueBinderBindError(t *testing.T) {
+	var v struct {
+		Name  string  `form:"name"`
+		Age   int     `form:"age"`
+		Score float64 `form:"score"`
+		Admin bool    `form:"admin"`
+	}
+	values := url.Values{"name": {"bob"}, "age": {"x"}, "score": {"1.5"}, "admin": {"maybe"}}
+	binder := URLValueBinder{TagName: formTag, Source: SourceQuery}
+	err := binder.BindForm(values, &v)
+
+	var bindErr *BindError
+	if !errors.As(err, &bindErr) {
+		t.Fatalf("expected *BindError, got %v", err)
+	}
+	if len(bindErr.Errors) != 2 {
+		t.Fatalf("expected 2 field errors, got %v", bindErr.Errors)
+	}
+	first := bindErr.Errors[0]
+	if first.Source != SourceQuery || first.Key != "age" || first.Value != "x" || first.Type != "int" {
+		t.Errorf("unexpected field error %+v", first)
+	}
+	if bindErr.Errors[1].Key != "admin" {
+		t.Errorf("unexpected field error %+v", bindErr.Errors[1])
+	}
+	if v.Name != "bob" || v.Score != 1.5 {
+		t.Errorf("valid fields should be bound, got %+v", v)
+	}
+}
+
+type bindBase struct {
+	ID int `form:"id"`
+}
+
+type bindItem struct {
+	ID   int    `form:"id"`
+	Name string `form:"name"`
+}
+
+type bindProfile struct {
+	Nickname string `form:"nickname"`
+}
+
+func TestURLValueBinderNested(t *testing.T) {
+	var v struct {
+		bindBase
+		User struct {
+			Name string `form:"name"`
+		} `form:"user"`
+		Profile *bindProfile      `form:"profile"`
+		Missing *bindProfile      `form:"missing"`
+		Items   []bindItem        `form:"items"`
+		Tags    []string          `form:"tags"`
+		Meta    map[string]int    `form:"meta"`
+		Labels  map[string]string `form:"labels"`
+	}
+	values := url.Values{
+		"id":               {"7"},
+		"user.name":        {"bob"},
+		"profile.nickname": {"b"},
+		"items[1].id":      {"2"},
+		"items[0].id":      {"1"},
+		"items[0].name":    {"first"},
+		"tags[]":           {"a", "b"},
+		"meta[x]":          {"1"},
+		"meta[y]":          {"2"},
+	}
+	binder := URLValueBinder{TagName: formTag}
+	if err := binder.BindForm(values, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.ID != 7 || v.User.Name != "bob" {
+		t.Errorf("unexpected %+v", v)
+	}
+	if v.Profile == nil || v.Profile.Nickname != "b" || v.Missing != nil {
+		t.Errorf("unexpected pointer fields %+v %+v", v.Profile, v.Missing)
+	}
+	if len(v.Items) != 2 || v.Items[0].Name != "first" || v.Items[1].ID != 2 {
+		t.Errorf("unexpected items %+v", v.Items)
+	}
+	if len(v.Tags) != 2 || v.Tags[1] != "b" {
+		t.Errorf("unexpected tags %+v", v.Tags)
+	}
+	if v.Meta["x"] != 1 || v.Meta["y"] != 2 || v.Labels != nil {
+		t.Errorf("unexpected maps %+v %+v", v.Meta, v.Labels)
+	}
+}
+
+type bindNode struct {
+	Name string    `form:"name"`
+	Next *bindNode `form:"next"`
+}
+
+func TestURLValueBinderRecursiveType(t *testing.T) {
+	var v bindNode
+	values := url.Values{"name": {"a"}, "next.name": {"b"}, "next.next.name": {"c"}}
+	binder := URLValueBinder{TagName: formTag}
+	if err := binder.BindForm(values, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Next == nil || v.Next.Next == nil || v.Next.Next.Name != "c" || v.Next.Next.Next != nil {
+		t.Errorf("unexpected %+v", v)
+	}
+}
+
+// embedded pointers are only allocated for exported types
+type EmbeddedNode struct {
+	*EmbeddedNode
+	Name string `form:"name"`
+}
+
+type EmbeddedA struct {
+	*EmbeddedB
+	A string `form:"a"`
+}
+
+type EmbeddedB struct {
+	*EmbeddedA
+	B string `form:"b"`
+}
+
+func TestURLValueBinderEmbeddedCycle(t *testing.T) {
+	binder := URLValueBinder{TagName: formTag}
+	var self EmbeddedNode
+	if err := binder.BindForm(url.Values{"name": {"a"}}, &self); err != nil {
+		t.Fatal(err)
+	}
+	if self.Name != "a" || self.EmbeddedNode != nil {
+		t.Errorf("unexpected %+v", self)
+	}
+
+	var a EmbeddedA
+	if err := binder.BindForm(url.Values{"a": {"1"}, "b": {"2"}}, &a); err != nil {
+		t.Fatal(err)
+	}
+	if a.A != "1" || a.EmbeddedB == nil || a.B != "2" || a.EmbeddedB.EmbeddedA != nil {
+		t.Errorf("unexpected %+v", a)
+	}
+}
+
+type bindLevel int
+
+func (l *bindLevel) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "low":
+		*l = 1
+	case "high":
+		*l = 2
+	default:
+		return errors.New("unknown level")
+	}
+	return nil
+}
+
+type bindCents int64
+
+func (c *bindCents) Scan(src interface{}) error {
+	f, err := strconv.ParseFloat(src.(string), 64)
+	*c = bindCents(f * 100)
+	return err
+}
+
+type bindCode struct{ value string }
+
+func TestURLValueBinderCustomTypes(t *testing.T) {
+	RegisterConverter(func(formValue string) (bindCode, error) {
+		return bindCode{value: strings.ToUpper(formValue)}, nil
+	})
+	var v struct {
+		At       time.Time     `form:"at"`
+		Day      *time.Time    `form:"day"`
+		Timeout  time.Duration `form:"timeout"`
+		IP       net.IP        `form:"ip"`
+		Homepage url.URL       `form:"homepage"`
+		Level    bindLevel     `form:"level"`
+		Levels   []bindLevel   `form:"levels"`
+		Price    bindCents     `form:"price"`
+		Code     bindCode      `form:"code"`
+	}
+	values := url.Values{
+		"at":       {"2022-01-02T03:04:05Z"},
+		"day":      {"2022-01-02"},
+		"timeout":  {"1m30s"},
+		"ip":       {"127.0.0.1"},
+		"homepage": {"https://example.com/a"},
+		"level":    {"high"},
+		"levels":   {"low", "high"},
+		"price":    {"1.25"},
+		"code":     {"abc"},
+	}
+	binder := URLValueBinder{TagName: formTag}
+	if err := binder.BindForm(values, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.At.Hour() != 3 || v.Day == nil || v.Day.Day() != 2 || v.Timeout != 90*time.Second {
+		t.Errorf("unexpected time fields %+v", v)
+	}
+	if !v.IP.Equal(net.IPv4(127, 0, 0, 1)) || v.Homepage.Host != "example.com" {
+		t.Errorf("unexpected ip or url %+v", v)
+	}
+	if v.Level != 2 || len(v.Levels) != 2 || v.Levels[0] != 1 || v.Price != 125 || v.Code.value != "ABC" {
+		t.Errorf("unexpected custom fields %+v", v)
+	}
+}
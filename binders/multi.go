@@ -0,0 +1,88 @@
+// Copyright 2022 eatmoreapple.  All rights reserved.
+// Use of this source code is governed by a GPL style
+// license that can be found in the LICENSE file.
+
+package binders
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// MultiSourceBinder binds each field from the source named by its tag in one pass
+//
+//	type Request struct {
+//		ID     int    `uri:"id"`
+//		Page   int    `query:"page,1"`
+//		Token  string `header:"X-Token"`
+//		Locale string `cookie:"locale"`
+//		Name   string `json:"name"`
+//	}
+//
+// The request body is decoded by Body first, then values of the other sources
+// are bound with the precedence form < cookie < header < query < uri,
+// which means the value of uri wins if a field is tagged with several sources
+// Only the fields tagged with the source explicitly will be bound from it
+type MultiSourceBinder struct {
+	// Body decodes the request body, such as JsonBodyBinder
+	// Body will be skipped if nil
+	Body Binder
+	// URIValues are the params of route
+	URIValues url.Values
+	// Config uses DefaultConfig if nil
+	Config *Config
+	// Presence records the fields found if not nil
+	// It should be shared with Body to record the fields of body
+	Presence *Presence
+}
+
+func (m MultiSourceBinder) Bind(request *http.Request, v interface{}) error {
+	if m.Body != nil && request.Body != nil && request.Body != http.NoBody {
+		// empty body is allowed
+		if err := m.Body.Bind(request, v); err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+	}
+	config := configOrDefault(m.Config)
+	bindErr := &BindError{}
+
+	// form values have been parsed by caller
+	if form := request.MultipartForm; form != nil {
+		binder := HttpMultipartFormBinder{URLValueBinder: m.overlay(config, config.FormTag, SourceForm), FieldTag: config.FileTag}
+		if err := bindErr.merge(binder.BindMultipartForm(form, v)); err != nil {
+			return err
+		}
+	} else if request.PostForm != nil {
+		binder := m.overlay(config, config.FormTag, SourceForm)
+		if err := bindErr.merge(binder.BindForm(request.PostForm, v)); err != nil {
+			return err
+		}
+	}
+
+	sources := []struct {
+		tag    string
+		source string
+		values url.Values
+	}{
+		{tag: config.CookieTag, source: SourceCookie, values: cookieValues(request)},
+		{tag: config.HeaderTag, source: SourceHeader, values: url.Values(request.Header)},
+		{tag: config.QueryTag, source: SourceQuery, values: request.URL.Query()},
+		{tag: config.URITag, source: SourceURI, values: m.URIValues},
+	}
+	for _, s := range sources {
+		binder := m.overlay(config, s.tag, s.source)
+		if err := bindErr.merge(binder.BindForm(s.values, v)); err != nil {
+			return err
+		}
+	}
+	return bindErr.err()
+}
+
+func (m MultiSourceBinder) overlay(config *Config, tagName, source string) URLValueBinder {
+	binder := config.URLValueBinder(tagName, source)
+	binder.Overlay = true
+	binder.Presence = m.Presence
+	return binder
+}
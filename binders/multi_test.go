@@ -0,0 +1,65 @@
+package binders
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/eatmoreapple/regia/serializers"
+)
+
+func TestMultiSourceBinder(t *testing.T) {
+	var v struct {
+		ID     int    `uri:"id" query:"id"`
+		Page   int    `query:"page,1"`
+		Size   int    `query:"size,10"`
+		Token  string `header:"X-Token"`
+		Locale string `cookie:"locale"`
+		Name   string `json:"name" query:"name"`
+		Email  string `json:"email"`
+		Plain  string
+	}
+	request := httptest.NewRequest(http.MethodPost, "/users/1?id=2&size=20&Plain=x", strings.NewReader(`{"name":"bob","email":"b@x.com"}`))
+	request.Header.Set("X-Token", "secret")
+	request.AddCookie(&http.Cookie{Name: "locale", Value: "en"})
+	binder := MultiSourceBinder{
+		Body:      JsonBodyBinder{Serializer: serializers.JsonSerializer{}},
+		URIValues: url.Values{"id": {"1"}},
+	}
+	if err := binder.Bind(request, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.ID != 1 || v.Page != 1 || v.Size != 20 || v.Token != "secret" || v.Locale != "en" {
+		t.Errorf("unexpected result %+v", v)
+	}
+	// values from body should not be overridden by absent keys or untagged fields
+	if v.Name != "bob" || v.Email != "b@x.com" || v.Plain != "" {
+		t.Errorf("unexpected result %+v", v)
+	}
+}
+
+func TestMultiSourceBinderBindError(t *testing.T) {
+	var v struct {
+		ID   int `uri:"id"`
+		Page int `query:"page"`
+		Age  int `header:"X-Age"`
+	}
+	request := httptest.NewRequest(http.MethodGet, "/?page=x", nil)
+	request.Header.Set("X-Age", "y")
+	binder := MultiSourceBinder{URIValues: url.Values{"id": {"z"}}}
+
+	var bindErr *BindError
+	if err := binder.Bind(request, &v); !errors.As(err, &bindErr) {
+		t.Fatalf("expected *BindError, got %v", err)
+	}
+	var sources []string
+	for _, err := range bindErr.Errors {
+		sources = append(sources, err.Source)
+	}
+	if strings.Join(sources, ",") != "header,query,uri" {
+		t.Errorf("unexpected field errors %v", bindErr.Errors)
+	}
+}
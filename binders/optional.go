@@ -0,0 +1,126 @@
+// Copyright 2022 eatmoreapple.  All rights reserved.
+// Use of this source code is governed by a GPL style
+// license that can be found in the LICENSE file.
+
+package binders
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+)
+
+// Optional holds a value which may be absent
+// It is valid only if the value has been set, so zero value can be told from missing
+//
+//	type PatchUser struct {
+//		Name Optional[string] `form:"name" json:"name"`
+//		Age  Optional[int]    `form:"age" json:"age"`
+//	}
+type Optional[T any] struct {
+	value T
+	valid bool
+}
+
+// Some returns a valid Optional with value
+func Some[T any](value T) Optional[T] {
+	return Optional[T]{value: value, valid: true}
+}
+
+// Set sets value and marks Optional valid
+func (o *Optional[T]) Set(value T) {
+	o.value = value
+	o.valid = true
+}
+
+// Valid reports whether the value has been set
+func (o Optional[T]) Valid() bool {
+	return o.valid
+}
+
+// Get returns the value and whether it has been set
+func (o Optional[T]) Get() (T, bool) {
+	return o.value, o.valid
+}
+
+// Value returns the value, it is zero value of T if not set
+func (o Optional[T]) Value() T {
+	return o.value
+}
+
+// Or returns the value if set, else returns fallback
+func (o Optional[T]) Or(fallback T) T {
+	if o.valid {
+		return o.value
+	}
+	return fallback
+}
+
+// Reset marks Optional invalid
+func (o *Optional[T]) Reset() {
+	var zero T
+	o.value = zero
+	o.valid = false
+}
+
+// MarshalJSON encodes the value, null will be returned if not set
+func (o Optional[T]) MarshalJSON() ([]byte, error) {
+	if !o.valid {
+		return []byte("null"), nil
+	}
+	return json.Marshal(o.value)
+}
+
+// UnmarshalJSON decodes the value and marks Optional valid
+// null keeps Optional invalid
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		o.Reset()
+		return nil
+	}
+	var value T
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	o.Set(value)
+	return nil
+}
+
+// optional is implemented by *Optional
+type optional interface {
+	// elemType returns the type of value
+	elemType() reflect.Type
+	// bindOptional binds the value with bind and marks Optional valid if succeed
+	bindOptional(bind func(value reflect.Value) error) error
+}
+
+func (o *Optional[T]) elemType() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+func (o *Optional[T]) bindOptional(bind func(value reflect.Value) error) error {
+	var value T
+	if err := bind(reflect.ValueOf(&value).Elem()); err != nil {
+		return err
+	}
+	o.Set(value)
+	return nil
+}
+
+var optionalType = reflect.TypeOf((*optional)(nil)).Elem()
+
+// isOptional reports whether the type is Optional
+func isOptional(typ reflect.Type) bool {
+	return typ.Kind() == reflect.Struct && reflect.PtrTo(typ).Implements(optionalType)
+}
+
+// optionalBindMethod returns BindMethod binds values into Optional of given type
+func optionalBindMethod(typ reflect.Type) BindMethod {
+	elem := reflect.New(typ).Interface().(optional).elemType()
+	bindElem := bindMethodFor(elem)
+	return func(field reflect.Value, formValues []string) error {
+		return field.Addr().Interface().(optional).bindOptional(func(value reflect.Value) error {
+			return bindElem(value, formValues)
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package binders
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/eatmoreapple/regia/serializers"
+)
+
+func TestURLValueBinderOptional(t *testing.T) {
+	var v struct {
+		Name    Optional[string]   `form:"name"`
+		Age     Optional[int]      `form:"age"`
+		Tags    Optional[[]string] `form:"tags"`
+		Missing Optional[int]      `form:"missing"`
+		Score   *int               `form:"score"`
+		Level   *int               `form:"level"`
+		Note    *string            `form:"note"`
+	}
+	values := url.Values{"name": {""}, "age": {"0"}, "tags": {"a", "b"}, "level": {""}, "note": {""}}
+	if err := (URLValueBinder{TagName: formTag}).BindForm(values, &v); err != nil {
+		t.Fatal(err)
+	}
+	if name, ok := v.Name.Get(); !ok || name != "" {
+		t.Errorf("name should be set to empty string, got %q %v", name, ok)
+	}
+	if !v.Age.Valid() || v.Age.Value() != 0 {
+		t.Errorf("age should be set to 0, got %+v", v.Age)
+	}
+	if tags := v.Tags.Value(); len(tags) != 2 || tags[1] != "b" {
+		t.Errorf("unexpected tags %v", tags)
+	}
+	if v.Missing.Valid() || v.Missing.Or(5) != 5 {
+		t.Errorf("missing should not be set, got %+v", v.Missing)
+	}
+	if v.Score != nil || v.Level != nil {
+		t.Errorf("pointers should be nil, got %v %v", v.Score, v.Level)
+	}
+	if v.Note == nil || *v.Note != "" {
+		t.Errorf("note should be set to empty string, got %v", v.Note)
+	}
+}
+
+func TestOptionalJSON(t *testing.T) {
+	var v struct {
+		Name Optional[string] `json:"name"`
+		Age  Optional[int]    `json:"age"`
+		Nick Optional[string] `json:"nick"`
+	}
+	if err := json.Unmarshal([]byte(`{"age":0,"nick":null}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Name.Valid() || !v.Age.Valid() || v.Nick.Valid() {
+		t.Errorf("unexpected result %+v", v)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"name":null,"age":0,"nick":null}` {
+		t.Errorf("unexpected json %s", data)
+	}
+}
+
+func TestPresence(t *testing.T) {
+	type profile struct {
+		Nickname string `form:"nickname" json:"nickname"`
+		Bio      string `form:"bio" json:"bio"`
+	}
+	var v struct {
+		bindBase
+		Name    string     `form:"name" json:"name"`
+		Age     int        `form:"age,18" json:"age"`
+		Profile profile    `form:"profile" json:"profile"`
+		Items   []bindItem `form:"items" json:"items"`
+	}
+	values := url.Values{"id": {"1"}, "name": {""}, "profile.nickname": {"b"}, "items[0].id": {"1"}}
+	presence := NewPresence()
+	if err := (URLValueBinder{TagName: formTag, Presence: presence}).BindForm(values, &v); err != nil {
+		t.Fatal(err)
+	}
+	fields := presence.Fields()
+	sort.Strings(fields)
+	if strings.Join(fields, ",") != "ID,Items,Items[0],Items[0].ID,Name,Profile,Profile.Nickname" {
+		t.Errorf("unexpected present fields %v", fields)
+	}
+	if presence.Present("Age") || v.Age != 18 {
+		t.Errorf("default value should not be present")
+	}
+
+	presence = NewPresence()
+	body := `{"id":1,"Name":"bob","profile":{"bio":""},"items":[{"name":"x"}]}`
+	request := httptest.NewRequest(http.MethodPatch, "/", strings.NewReader(body))
+	binder := JsonBodyBinder{Serializer: serializers.JsonSerializer{}, Presence: presence}
+	if err := binder.Bind(request, &v); err != nil {
+		t.Fatal(err)
+	}
+	fields = presence.Fields()
+	sort.Strings(fields)
+	if strings.Join(fields, ",") != "ID,Items,Items[0],Items[0].Name,Name,Profile,Profile.Bio" {
+		t.Errorf("unexpected present fields %v", fields)
+	}
+}
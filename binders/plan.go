@@ -0,0 +1,285 @@
+// Copyright 2022 eatmoreapple.  All rights reserved.
+// Use of this source code is governed by a GPL style
+// license that can be found in the LICENSE file.
+
+package binders
+
+import (
+	"mime/multipart"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+var (
+	fileHeaderType = reflect.TypeOf((*multipart.FileHeader)(nil))
+	fileSliceType  = reflect.TypeOf([]*multipart.FileHeader(nil))
+)
+
+type fieldKind uint8
+
+const (
+	// kindValue is bound from the values of its key directly
+	kindValue fieldKind = iota
+	// kindStruct is bound from keys like key.name
+	kindStruct
+	// kindPtrStruct is the same as kindStruct but allocated only if any value found
+	kindPtrStruct
+	// kindSlice is bound from keys like key[], key[0] and key[0].name
+	kindSlice
+	// kindMap is bound from keys like key[name]
+	kindMap
+	// kindFile is bound from upload files
+	kindFile
+	// kindEmbeddedPtr is a pointer of anonymous struct which fields are promoted
+	kindEmbeddedPtr
+)
+
+// fieldPlan is the compiled binding information of struct field
+type fieldPlan struct {
+	// index is the index sequence for reflect.Value.FieldByIndex
+	// fields of embedded struct have been flattened
+	index []int
+	// name is the name of Go field, used to record Presence
+	name     string
+	key      string
+	defaults []string
+	kind     fieldKind
+	typ      reflect.Type
+	// bindMethod is the name of custom BindMethod
+	bindMethod string
+	// fileKey is the key of upload files
+	fileKey string
+	// fileConstraint checks upload files if not nil
+	fileConstraint *fileConstraint
+	// setter binds values into field of kindValue
+	setter BindMethod
+	// elem is the plan for element of slice, map and struct
+	elem *elemPlan
+}
+
+// elemPlan is the compiled binding information of slice element, map value or struct
+type elemPlan struct {
+	typ    reflect.Type
+	ptr    bool
+	plan   *structPlan
+	setter BindMethod
+}
+
+// structPlan is the compiled binding information of struct
+type structPlan struct {
+	fields []*fieldPlan
+}
+
+// planKey identifies a structPlan, same type bound with different tags has different plan
+type planKey struct {
+	typ     reflect.Type
+	tag     string
+	bindTag string
+	fileTag string
+	// taggedOnly skips the fields without tag
+	taggedOnly bool
+}
+
+var (
+	// plans caches *structPlan by planKey
+	plans sync.Map
+	// compileLock ensures that only complete plan will be stored into plans
+	compileLock sync.Mutex
+)
+
+// planCompiler compiles structPlan for given tags
+type planCompiler struct {
+	tag     string
+	bindTag string
+	fileTag string
+	// taggedOnly skips the fields without tag
+	taggedOnly bool
+	// compiling holds plans being compiled to resolve recursive types
+	compiling map[reflect.Type]*structPlan
+}
+
+// getStructPlan returns the cached plan of given struct type
+func getStructPlan(t reflect.Type, tag, bindTag, fileTag string, taggedOnly bool) *structPlan {
+	key := planKey{typ: t, tag: tag, bindTag: bindTag, fileTag: fileTag, taggedOnly: taggedOnly}
+	if plan, ok := plans.Load(key); ok {
+		return plan.(*structPlan)
+	}
+	compileLock.Lock()
+	defer compileLock.Unlock()
+	if plan, ok := plans.Load(key); ok {
+		return plan.(*structPlan)
+	}
+	compiler := &planCompiler{
+		tag:        tag,
+		bindTag:    bindTag,
+		fileTag:    fileTag,
+		taggedOnly: taggedOnly,
+		compiling:  make(map[reflect.Type]*structPlan),
+	}
+	plan := compiler.compile(t)
+	for typ, p := range compiler.compiling {
+		plans.Store(compiler.key(typ), p)
+	}
+	return plan
+}
+
+func (p *planCompiler) compile(t reflect.Type) *structPlan {
+	if plan, ok := p.compiling[t]; ok {
+		return plan
+	}
+	if plan, ok := plans.Load(p.key(t)); ok {
+		return plan.(*structPlan)
+	}
+	plan := &structPlan{}
+	p.compiling[t] = plan
+	p.compileFields(plan, t, nil)
+	return plan
+}
+
+func (p *planCompiler) key(t reflect.Type) planKey {
+	return planKey{typ: t, tag: p.tag, bindTag: p.bindTag, fileTag: p.fileTag, taggedOnly: p.taggedOnly}
+}
+
+func (p *planCompiler) compileFields(plan *structPlan, t reflect.Type, index []int) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		fieldIndex := append(append([]int(nil), index...), i)
+
+		tag, exist := field.Tag.Lookup(p.tag)
+
+		// promote fields of anonymous embedded struct
+		if field.Anonymous && !exist {
+			switch {
+			case field.Type.Kind() == reflect.Struct:
+				p.compileFields(plan, field.Type, fieldIndex)
+			case field.Type.Kind() == reflect.Ptr && field.Type.Elem().Kind() == reflect.Struct && field.IsExported():
+				// pointer of unexported struct can not be allocated
+				plan.fields = append(plan.fields, &fieldPlan{
+					index: fieldIndex,
+					kind:  kindEmbeddedPtr,
+					typ:   field.Type,
+					elem:  &elemPlan{typ: field.Type.Elem(), ptr: true, plan: p.compile(field.Type.Elem())},
+				})
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if !exist {
+			if p.taggedOnly && !p.hasFileTag(field) {
+				continue
+			}
+			// set default tag value
+			tag = field.Name
+		}
+
+		tags := strings.Split(tag, ",")
+		if tags[0] == pass {
+			continue
+		}
+		fp := &fieldPlan{index: fieldIndex, name: field.Name, key: tags[0], defaults: tags[1:], typ: field.Type}
+
+		switch {
+		case field.Type == fileHeaderType || field.Type == fileSliceType:
+			fp.kind = kindFile
+			fp.fileKey = fp.key
+			if fileTag, exist := field.Tag.Lookup(p.fileTag); exist {
+				fileKey, constraint, err := parseFileTag(fileTag)
+				if err != nil {
+					panic(err.Error() + " of " + t.String() + "." + field.Name)
+				}
+				if len(fileKey) > 0 {
+					fp.fileKey = fileKey
+				}
+				fp.fileConstraint = constraint
+			}
+			if fp.fileKey == pass {
+				continue
+			}
+		default:
+			if bindMethod, found := field.Tag.Lookup(p.bindTag); found {
+				fp.bindMethod = bindMethod
+			}
+			p.compileKind(fp)
+		}
+		plan.fields = append(plan.fields, fp)
+	}
+}
+
+// hasFileTag reports whether the upload file field has file tag
+func (p *planCompiler) hasFileTag(field reflect.StructField) bool {
+	if field.Type != fileHeaderType && field.Type != fileSliceType {
+		return false
+	}
+	_, exist := field.Tag.Lookup(p.fileTag)
+	return exist && len(p.fileTag) > 0
+}
+
+// compileKind decides how to bind the field
+func (p *planCompiler) compileKind(fp *fieldPlan) {
+	// values found with exact key will be bound by setter whatever kind it is
+	fp.setter = bindMethodFor(fp.typ)
+	switch t := fp.typ; {
+	case isNestedStruct(t):
+		fp.kind = kindStruct
+		fp.elem = &elemPlan{typ: t, plan: p.compile(t)}
+	case t.Kind() == reflect.Ptr && isNestedStruct(t.Elem()):
+		fp.kind = kindPtrStruct
+		fp.elem = &elemPlan{typ: t.Elem(), ptr: true, plan: p.compile(t.Elem())}
+	case t.Kind() == reflect.Slice && !isConvertible(t):
+		fp.kind = kindSlice
+		fp.elem = p.compileElem(t.Elem())
+	case t.Kind() == reflect.Map && t.Key().Kind() == reflect.String && !isConvertible(t):
+		fp.kind = kindMap
+		fp.elem = p.compileElem(t.Elem())
+	default:
+		fp.kind = kindValue
+	}
+}
+
+func (p *planCompiler) compileElem(t reflect.Type) *elemPlan {
+	elem := &elemPlan{typ: t, setter: bindMethodFor(t)}
+	switch {
+	case isNestedStruct(t):
+		elem.plan = p.compile(t)
+	case t.Kind() == reflect.Ptr && isNestedStruct(t.Elem()):
+		elem.ptr = true
+		elem.plan = p.compile(t.Elem())
+	}
+	return elem
+}
+
+// isNestedStruct reports whether the fields of struct should be bound one by one
+func isNestedStruct(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && !isConvertible(t)
+}
+
+// bindMethodFor returns BindMethod binds values into given type
+func bindMethodFor(t reflect.Type) BindMethod {
+	if isOptional(t) {
+		return optionalBindMethod(t)
+	}
+	if convert, ok := converterFor(t); ok {
+		return func(field reflect.Value, formValues []string) error {
+			if len(formValues) > 0 {
+				return convert(field, formValues[0])
+			}
+			return nil
+		}
+	}
+	switch t.Kind() {
+	case reflect.Array:
+		return bindArray
+	case reflect.Slice:
+		return bindSlice
+	default:
+		return func(field reflect.Value, formValues []string) error {
+			if len(formValues) > 0 {
+				return bindSingle(field, formValues[0])
+			}
+			return nil
+		}
+	}
+}
@@ -0,0 +1,185 @@
+// Copyright 2022 eatmoreapple.  All rights reserved.
+// Use of this source code is governed by a GPL style
+// license that can be found in the LICENSE file.
+
+package binders
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Presence records the fields found in the request while binding
+// Fields are addressed by the path of Go field names, such as
+//
+//	Name, User.Name, Items[0].ID, Meta[key]
+//
+// Fields of anonymous embedded struct are promoted like Go does
+// Values bound from default value of tag are not present
+type Presence struct {
+	fields map[string]struct{}
+}
+
+// NewPresence constructor for Presence
+func NewPresence() *Presence {
+	return &Presence{fields: make(map[string]struct{})}
+}
+
+// Present reports whether the field is found in the request
+// The parent of present field is present too
+func (p *Presence) Present(field string) bool {
+	if p == nil {
+		return false
+	}
+	_, exist := p.fields[field]
+	return exist
+}
+
+// Fields returns the present fields
+func (p *Presence) Fields() []string {
+	if p == nil {
+		return nil
+	}
+	fields := make([]string, 0, len(p.fields))
+	for field := range p.fields {
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// add records the field and its parents
+func (p *Presence) add(field string) {
+	if p == nil || len(field) == 0 {
+		return
+	}
+	if p.fields == nil {
+		p.fields = make(map[string]struct{})
+	}
+	for i := 0; i < len(field); i++ {
+		if field[i] == '.' || field[i] == '[' {
+			p.fields[field[:i]] = struct{}{}
+		}
+	}
+	p.fields[field] = struct{}{}
+}
+
+// joinPath returns the path of field name under parent
+func joinPath(parent, name string) string {
+	if len(parent) == 0 {
+		return name
+	}
+	return parent + "." + name
+}
+
+// indexPath returns the path of element under parent
+func indexPath(parent string, index string) string {
+	return parent + "[" + index + "]"
+}
+
+// addJSON records the fields present in the json object data
+// Nested objects will be recorded as well
+func (p *Presence) addJSON(data []byte, typ reflect.Type) {
+	if p == nil {
+		return
+	}
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return
+	}
+	p.addJSONObject("", bytes.TrimSpace(data), typ)
+}
+
+func (p *Presence) addJSONObject(path string, data []byte, typ reflect.Type) {
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(data, &object); err != nil {
+		return
+	}
+	for key, raw := range object {
+		field, ok := jsonField(typ, key)
+		if !ok {
+			continue
+		}
+		fieldPath := joinPath(path, field.Name)
+		p.add(fieldPath)
+		p.addJSONValue(fieldPath, raw, field.Type)
+	}
+}
+
+func (p *Presence) addJSONValue(path string, raw json.RawMessage, typ reflect.Type) {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
+		return
+	}
+	switch {
+	case raw[0] == '{' && isNestedStruct(typ) && !isOptional(typ):
+		p.addJSONObject(path, raw, typ)
+	case raw[0] == '[' && (typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array):
+		var items []json.RawMessage
+		if err := json.Unmarshal(raw, &items); err != nil {
+			return
+		}
+		for index, item := range items {
+			itemPath := indexPath(path, strconv.Itoa(index))
+			p.add(itemPath)
+			p.addJSONValue(itemPath, item, typ.Elem())
+		}
+	case raw[0] == '{' && typ.Kind() == reflect.Map && typ.Key().Kind() == reflect.String:
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &object); err != nil {
+			return
+		}
+		for key, item := range object {
+			itemPath := indexPath(path, key)
+			p.add(itemPath)
+			p.addJSONValue(itemPath, item, typ.Elem())
+		}
+	}
+}
+
+// jsonField finds the struct field decoded from json key like encoding/json does
+// Fields of anonymous embedded struct are promoted
+func jsonField(typ reflect.Type, key string) (reflect.StructField, bool) {
+	var fold reflect.StructField
+	var folded bool
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		tag := field.Tag.Get("json")
+		name := strings.Split(tag, ",")[0]
+		if name == pass {
+			continue
+		}
+		if field.Anonymous && len(name) == 0 {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				if f, ok := jsonField(embedded, key); ok {
+					return f, true
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if len(name) == 0 {
+			name = field.Name
+		}
+		if name == key {
+			return field, true
+		}
+		if !folded && strings.EqualFold(name, key) {
+			fold, folded = field, true
+		}
+	}
+	return fold, folded
+}
@@ -0,0 +1,301 @@
+// Copyright 2022 eatmoreapple.  All rights reserved.
+// Use of this source code is governed by a GPL style
+// license that can be found in the LICENSE file.
+
+package binders
+
+import (
+	"errors"
+	"mime/multipart"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxSliceIndex limits the length of slice allocated by client
+const maxSliceIndex = 1000
+
+// formWalker binds url.Values and upload files into struct by structPlan
+type formWalker struct {
+	binder  *URLValueBinder
+	form    url.Values
+	files   map[string][]*multipart.FileHeader
+	fileTag string
+	bindErr *BindError
+	// presence records the fields found, can be nil
+	presence *Presence
+	// embedding holds the structs whose embedded pointers are being bound
+	embedding map[embeddedStruct]bool
+}
+
+// embeddedStruct identifies a struct bound at prefix
+type embeddedStruct struct {
+	prefix string
+	plan   *structPlan
+}
+
+func (w *formWalker) bind(value reflect.Value) error {
+	plan := getStructPlan(value.Type(), w.binder.TagName, w.binder.BindTagName, w.fileTag, w.binder.Overlay)
+	return w.bindStruct("", "", value, plan)
+}
+
+// bindStruct binds fields of struct
+// prefix is the key prefix of values, path is the Go field path used by Presence
+func (w *formWalker) bindStruct(prefix, path string, value reflect.Value, plan *structPlan) error {
+	for _, field := range plan.fields {
+		fieldValue := value.FieldByIndex(field.index)
+		if field.kind == kindEmbeddedPtr {
+			if err := w.bindEmbeddedPtr(prefix, path, fieldValue, plan, field.elem.plan); err != nil {
+				return err
+			}
+			continue
+		}
+		key := prefix + field.key
+		fieldPath := joinPath(path, field.name)
+
+		// upload files
+		if field.kind == kindFile {
+			w.bindFiles(fieldPath, field, fieldValue)
+			continue
+		}
+
+		// if we need custom bind by self
+		if len(field.bindMethod) > 0 {
+			formValue, exist := w.form[key]
+			if !exist {
+				continue
+			}
+			w.presence.add(fieldPath)
+			method := w.binder.BindMethods[field.bindMethod]
+			if method == nil {
+				return errors.New("no method named " + field.bindMethod)
+			}
+			if err := method(fieldValue, formValue); err != nil {
+				w.bindErr.add(newFieldError(w.binder.Source, key, formValue, field.typ, err))
+			}
+			continue
+		}
+
+		if err := w.bindField(key, fieldPath, fieldValue, field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bindEmbeddedPtr binds the promoted fields of embedded struct pointer
+// A struct embedding itself, directly or through others, would be allocated forever,
+// so it is bound once at the same prefix, the deeper fields are shadowed by the outer ones anyway
+func (w *formWalker) bindEmbeddedPtr(prefix, path string, fieldValue reflect.Value, outer, plan *structPlan) error {
+	if w.embedding == nil {
+		w.embedding = make(map[embeddedStruct]bool)
+	}
+	key := embeddedStruct{prefix: prefix, plan: outer}
+	if !w.embedding[key] {
+		w.embedding[key] = true
+		defer delete(w.embedding, key)
+	}
+	if w.embedding[embeddedStruct{prefix: prefix, plan: plan}] {
+		return nil
+	}
+	return w.bindPtrStruct(prefix, path, fieldValue, plan)
+}
+
+// bindPtrStruct allocates the struct only if any value found
+func (w *formWalker) bindPtrStruct(prefix, path string, fieldValue reflect.Value, plan *structPlan) error {
+	if fieldValue.IsNil() {
+		elem := reflect.New(fieldValue.Type().Elem())
+		if err := w.bindStruct(prefix, path, elem.Elem(), plan); err != nil {
+			return err
+		}
+		if !elem.Elem().IsZero() {
+			fieldValue.Set(elem)
+		}
+		return nil
+	}
+	return w.bindStruct(prefix, path, fieldValue.Elem(), plan)
+}
+
+func (w *formWalker) bindField(key, path string, fieldValue reflect.Value, field *fieldPlan) error {
+	// value found with exact key
+	if formValue, exist := w.form[key]; exist && !w.useDefault(field, formValue) {
+		w.presence.add(path)
+		if err := field.setter(fieldValue, formValue); err != nil {
+			w.bindErr.add(newFieldError(w.binder.Source, key, formValue, field.typ, err))
+		}
+		return nil
+	}
+
+	switch field.kind {
+	case kindStruct:
+		return w.bindStruct(key+".", path, fieldValue, field.elem.plan)
+	case kindPtrStruct:
+		if w.hasPrefix(key + ".") {
+			return w.bindPtrStruct(key+".", path, fieldValue, field.elem.plan)
+		}
+		return nil
+	case kindSlice:
+		// tags[]=a&tags[]=b
+		if formValue, exist := w.form[key+"[]"]; exist {
+			w.presence.add(path)
+			if err := field.setter(fieldValue, formValue); err != nil {
+				w.bindErr.add(newFieldError(w.binder.Source, key+"[]", formValue, field.typ, err))
+			}
+			return nil
+		}
+		// items[0].id=1&items[1]=2
+		if indexes := w.indexes(key); len(indexes) > 0 {
+			return w.bindIndexedSlice(key, path, fieldValue, field.elem, indexes)
+		}
+	case kindMap:
+		// meta[key]=value
+		if keys := w.mapKeys(key); len(keys) > 0 {
+			return w.bindMap(key, path, fieldValue, field.elem, keys)
+		}
+	}
+
+	// try to bind default value
+	// the value bound from other sources will be kept when overlay
+	if len(field.defaults) > 0 && !w.binder.DisableDefault && !(w.binder.Overlay && !fieldValue.IsZero()) {
+		if err := field.setter(fieldValue, field.defaults); err != nil {
+			w.bindErr.add(newFieldError(w.binder.Source, key, field.defaults, field.typ, err))
+		}
+	}
+	return nil
+}
+
+// useDefault reports whether the default value should be used instead of empty values
+func (w *formWalker) useDefault(field *fieldPlan, formValue []string) bool {
+	if !w.binder.DefaultOnEmpty || w.binder.DisableDefault || len(field.defaults) == 0 {
+		return false
+	}
+	for _, value := range formValue {
+		if len(value) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (w *formWalker) bindIndexedSlice(key, path string, fieldValue reflect.Value, elem *elemPlan, indexes []int) error {
+	length := indexes[len(indexes)-1] + 1
+	slice := reflect.MakeSlice(fieldValue.Type(), length, length)
+	for _, index := range indexes {
+		elemKey := key + "[" + strconv.Itoa(index) + "]"
+		elemPath := indexPath(path, strconv.Itoa(index))
+		if err := w.bindElem(elemKey, elemPath, slice.Index(index), elem); err != nil {
+			return err
+		}
+	}
+	fieldValue.Set(slice)
+	return nil
+}
+
+func (w *formWalker) bindMap(key, path string, fieldValue reflect.Value, elem *elemPlan, keys []string) error {
+	mapType := fieldValue.Type()
+	if fieldValue.IsNil() {
+		fieldValue.Set(reflect.MakeMapWithSize(mapType, len(keys)))
+	}
+	for _, mapKey := range keys {
+		value := reflect.New(elem.typ).Elem()
+		if err := w.bindElem(key+"["+mapKey+"]", indexPath(path, mapKey), value, elem); err != nil {
+			return err
+		}
+		fieldValue.SetMapIndex(reflect.ValueOf(mapKey).Convert(mapType.Key()), value)
+	}
+	return nil
+}
+
+// bindElem binds element of slice or map
+func (w *formWalker) bindElem(key, path string, value reflect.Value, elem *elemPlan) error {
+	if formValue, exist := w.form[key]; exist {
+		w.presence.add(path)
+		if err := elem.setter(value, formValue); err != nil {
+			w.bindErr.add(newFieldError(w.binder.Source, key, formValue, elem.typ, err))
+		}
+		return nil
+	}
+	if elem.plan == nil {
+		return nil
+	}
+	if elem.ptr {
+		return w.bindPtrStruct(key+".", path, value, elem.plan)
+	}
+	return w.bindStruct(key+".", path, value, elem.plan)
+}
+
+func (w *formWalker) bindFiles(path string, field *fieldPlan, fieldValue reflect.Value) {
+	if files, exist := w.files[field.fileKey]; exist {
+		w.presence.add(path)
+		if field.fileConstraint != nil {
+			if err := field.fileConstraint.check(files); err != nil {
+				w.bindErr.add(newFieldError(SourceFile, field.fileKey, fileNames(files), field.typ, err))
+				return
+			}
+		}
+		if err := bindFile(fieldValue, files); err != nil {
+			w.bindErr.add(newFieldError(SourceFile, field.fileKey, fileNames(files), field.typ, err))
+		}
+	}
+}
+
+// hasPrefix reports whether any key starts with prefix
+func (w *formWalker) hasPrefix(prefix string) bool {
+	for k := range w.form {
+		if strings.HasPrefix(k, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// indexes returns sorted indexes of keys like key[0], key[1].name
+func (w *formWalker) indexes(key string) []int {
+	var indexes []int
+	seen := make(map[int]struct{})
+	for _, sub := range w.subKeys(key) {
+		index, err := strconv.Atoi(sub)
+		if err != nil || index < 0 || index > maxSliceIndex {
+			continue
+		}
+		if _, ok := seen[index]; !ok {
+			seen[index] = struct{}{}
+			indexes = append(indexes, index)
+		}
+	}
+	sort.Ints(indexes)
+	return indexes
+}
+
+// mapKeys returns keys of map like meta[a], meta[b]
+func (w *formWalker) mapKeys(key string) []string {
+	var keys []string
+	seen := make(map[string]struct{})
+	for _, sub := range w.subKeys(key) {
+		if _, ok := seen[sub]; !ok {
+			seen[sub] = struct{}{}
+			keys = append(keys, sub)
+		}
+	}
+	return keys
+}
+
+// subKeys returns the content in first brackets of keys start with key[
+func (w *formWalker) subKeys(key string) []string {
+	var keys []string
+	prefix := key + "["
+	for k := range w.form {
+		if !strings.HasPrefix(k, prefix) {
+			continue
+		}
+		rest := k[len(prefix):]
+		end := strings.IndexByte(rest, ']')
+		if end <= 0 {
+			continue
+		}
+		keys = append(keys, rest[:end])
+	}
+	return keys
+}
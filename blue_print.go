@@ -12,13 +12,17 @@ import (
 	"reflect"
 	"strings"
 
-	"github.com/eatmoreapple/regia/internal"
+	"github.com/eatmoreapple/regia/binders"
+	"github.com/eatmoreapple/regia/serializers"
+	"github.com/eatmoreapple/regia/validators"
 )
 
 type handleNode struct {
 	path      string
 	group     HandleFuncGroup
 	blueprint *BluePrint
+	// middleware count of BluePrint middlewares at the head of group
+	middleware int
 }
 
 type BluePrint struct {
@@ -34,11 +38,27 @@ type BluePrint struct {
 
 	// response render
 	htmlLoader    HTMLLoader
-	xmlSerializer internal.Serializer
+	xmlSerializer serializers.Serializer
 	// JSONSerializer used to serialize json
 	// Your set your own JSONSerializer if you want
 	// Such as jsoniter, json2, etc
-	jsonSerializer internal.Serializer
+	jsonSerializer    serializers.Serializer
+	yamlSerializer    serializers.Serializer
+	tomlSerializer    serializers.Serializer
+	msgPackSerializer serializers.Serializer
+	cborSerializer    serializers.Serializer
+	// ProtobufSerializer only accepts proto.Message
+	protobufSerializer serializers.Serializer
+
+	// binderConfig is used by binders of Context
+	binderConfig *binders.Config
+
+	// validator validates the destination after binding
+	validator validators.Validator
+
+	// request body size limit
+	maxBodySize             int64
+	maxDecompressedBodySize int64
 
 	parent      *BluePrint
 	methodsTree map[string][]*handleNode
@@ -104,7 +124,7 @@ func (b *BluePrint) RAW(method, path string, handlers ...http.HandlerFunc) {
 func (b *BluePrint) Handle(method, path string, group ...HandleFunc) {
 	group = append(b.middleware, group...)
 	path = b.prefix + path
-	n := &handleNode{path: path, group: group, blueprint: b}
+	n := &handleNode{path: path, group: group, blueprint: b, middleware: len(b.middleware)}
 	b.register(method, n)
 }
 
@@ -216,7 +236,7 @@ func (b *BluePrint) IsRoot() bool {
 
 // XMLSerializer returns XMLSerializer
 // If not set, it will try to get from parent BluePrint
-func (b *BluePrint) XMLSerializer() internal.Serializer {
+func (b *BluePrint) XMLSerializer() serializers.Serializer {
 	if b.xmlSerializer != nil {
 		return b.xmlSerializer
 	}
@@ -228,7 +248,7 @@ func (b *BluePrint) XMLSerializer() internal.Serializer {
 
 // SetXMLSerializer set XMLSerializer
 // If is nil, it will be panic
-func (b *BluePrint) SetXMLSerializer(xmlSerializer internal.Serializer) {
+func (b *BluePrint) SetXMLSerializer(xmlSerializer serializers.Serializer) {
 	if xmlSerializer == nil {
 		panic("xmlSerializer can not be nil")
 	}
@@ -237,7 +257,7 @@ func (b *BluePrint) SetXMLSerializer(xmlSerializer internal.Serializer) {
 
 // JSONSerializer returns JSONSerializer
 // If not set, it will try to get from parent BluePrint
-func (b *BluePrint) JSONSerializer() internal.Serializer {
+func (b *BluePrint) JSONSerializer() serializers.Serializer {
 	if b.jsonSerializer != nil {
 		return b.jsonSerializer
 	}
@@ -249,13 +269,118 @@ func (b *BluePrint) JSONSerializer() internal.Serializer {
 
 // SetJSONSerializer set JSONSerializer
 // If is nil, it will be panic
-func (b *BluePrint) SetJSONSerializer(jsonSerializer internal.Serializer) {
+func (b *BluePrint) SetJSONSerializer(jsonSerializer serializers.Serializer) {
 	if jsonSerializer == nil {
 		panic("jsonSerializer can not be nil")
 	}
 	b.jsonSerializer = jsonSerializer
 }
 
+// YAMLSerializer returns YAMLSerializer
+// If not set, it will try to get from parent BluePrint
+func (b *BluePrint) YAMLSerializer() serializers.Serializer {
+	if b.yamlSerializer != nil {
+		return b.yamlSerializer
+	}
+	if !b.IsRoot() {
+		return b.Parent().YAMLSerializer()
+	}
+	return nil
+}
+
+// SetYAMLSerializer set YAMLSerializer
+// If is nil, it will be panic
+func (b *BluePrint) SetYAMLSerializer(yamlSerializer serializers.Serializer) {
+	if yamlSerializer == nil {
+		panic("yamlSerializer can not be nil")
+	}
+	b.yamlSerializer = yamlSerializer
+}
+
+// TOMLSerializer returns TOMLSerializer
+// If not set, it will try to get from parent BluePrint
+func (b *BluePrint) TOMLSerializer() serializers.Serializer {
+	if b.tomlSerializer != nil {
+		return b.tomlSerializer
+	}
+	if !b.IsRoot() {
+		return b.Parent().TOMLSerializer()
+	}
+	return nil
+}
+
+// SetTOMLSerializer set TOMLSerializer
+// If is nil, it will be panic
+func (b *BluePrint) SetTOMLSerializer(tomlSerializer serializers.Serializer) {
+	if tomlSerializer == nil {
+		panic("tomlSerializer can not be nil")
+	}
+	b.tomlSerializer = tomlSerializer
+}
+
+// MsgPackSerializer returns MsgPackSerializer
+// If not set, it will try to get from parent BluePrint
+func (b *BluePrint) MsgPackSerializer() serializers.Serializer {
+	if b.msgPackSerializer != nil {
+		return b.msgPackSerializer
+	}
+	if !b.IsRoot() {
+		return b.Parent().MsgPackSerializer()
+	}
+	return nil
+}
+
+// SetMsgPackSerializer set MsgPackSerializer
+// If is nil, it will be panic
+func (b *BluePrint) SetMsgPackSerializer(msgPackSerializer serializers.Serializer) {
+	if msgPackSerializer == nil {
+		panic("msgPackSerializer can not be nil")
+	}
+	b.msgPackSerializer = msgPackSerializer
+}
+
+// CBORSerializer returns CBORSerializer
+// If not set, it will try to get from parent BluePrint
+func (b *BluePrint) CBORSerializer() serializers.Serializer {
+	if b.cborSerializer != nil {
+		return b.cborSerializer
+	}
+	if !b.IsRoot() {
+		return b.Parent().CBORSerializer()
+	}
+	return nil
+}
+
+// SetCBORSerializer set CBORSerializer
+// If is nil, it will be panic
+func (b *BluePrint) SetCBORSerializer(cborSerializer serializers.Serializer) {
+	if cborSerializer == nil {
+		panic("cborSerializer can not be nil")
+	}
+	b.cborSerializer = cborSerializer
+}
+
+// ProtobufSerializer returns ProtobufSerializer
+// If not set, it will try to get from parent BluePrint
+func (b *BluePrint) ProtobufSerializer() serializers.Serializer {
+	if b.protobufSerializer != nil {
+		return b.protobufSerializer
+	}
+	if !b.IsRoot() {
+		return b.Parent().ProtobufSerializer()
+	}
+	return nil
+}
+
+// SetProtobufSerializer set ProtobufSerializer
+// If is nil, it will be panic
+func (b *BluePrint) SetProtobufSerializer(protobufSerializer serializers.Serializer) {
+	if protobufSerializer == nil {
+		panic("protobufSerializer can not be nil")
+	}
+	b.protobufSerializer = protobufSerializer
+}
+
 // HTMLLoader HTMLSerializer returns HTMLLoader
 // If not set, it will try to get from parent BluePrint
 func (b *BluePrint) HTMLLoader() HTMLLoader {
@@ -318,6 +443,109 @@ func (b *BluePrint) SetParsers(parsers Parsers) {
 	b.parsers = parsers
 }
 
+// BinderConfig returns binders.Config
+// If not set, it will try to get from parent BluePrint
+func (b *BluePrint) BinderConfig() *binders.Config {
+	if b.binderConfig != nil {
+		return b.binderConfig
+	}
+	if !b.IsRoot() {
+		return b.Parent().BinderConfig()
+	}
+	return nil
+}
+
+// SetBinderConfig set binders.Config
+// If is nil, it will be panic
+func (b *BluePrint) SetBinderConfig(config *binders.Config) {
+	if config == nil {
+		panic("binderConfig can not be nil")
+	}
+	b.binderConfig = config
+}
+
+// AddBindMethod add BindMethod for fields with bind tag, such as `bind:"unix"`
+// If current BluePrint has no its own binders.Config,
+// the inherited one will be copied to avoid affecting the parent BluePrint,
+// so it should be called after the BluePrint has been included
+func (b *BluePrint) AddBindMethod(name string, method binders.BindMethod) error {
+	if b.binderConfig == nil {
+		if config := b.BinderConfig(); config != nil {
+			b.binderConfig = config.Clone()
+		} else {
+			b.binderConfig = binders.DefaultConfig()
+		}
+	}
+	return b.binderConfig.AddBindMethod(name, method)
+}
+
+// Validator returns Validator
+// If not set, it will try to get from parent BluePrint
+func (b *BluePrint) Validator() validators.Validator {
+	if b.validator != nil {
+		return b.validator
+	}
+	if !b.IsRoot() {
+		return b.Parent().Validator()
+	}
+	return nil
+}
+
+// SetValidator set Validator
+// If is nil, it will be panic
+func (b *BluePrint) SetValidator(validator validators.Validator) {
+	if validator == nil {
+		panic("validator can not be nil")
+	}
+	b.validator = validator
+}
+
+// MaxBodySize returns max size of request body
+// If not set, it will try to get from parent BluePrint
+// Zero or negative value means no limit
+func (b *BluePrint) MaxBodySize() int64 {
+	if b.maxBodySize != 0 {
+		return b.maxBodySize
+	}
+	if !b.IsRoot() {
+		return b.Parent().MaxBodySize()
+	}
+	return 0
+}
+
+// SetMaxBodySize set max size of request body, default is 32MB
+// Request with larger body will get 413
+// Use NoBodyLimit to disable the limit of parent BluePrint, such as for huge uploads
+func (b *BluePrint) SetMaxBodySize(size int64) {
+	if size == 0 {
+		panic("maxBodySize can not be zero")
+	}
+	b.maxBodySize = size
+}
+
+// MaxDecompressedBodySize returns max size of decompressed request body
+// If not set, it will try to get from parent BluePrint
+// Zero or negative value means no limit
+func (b *BluePrint) MaxDecompressedBodySize() int64 {
+	if b.maxDecompressedBodySize != 0 {
+		return b.maxDecompressedBodySize
+	}
+	if !b.IsRoot() {
+		return b.Parent().MaxDecompressedBodySize()
+	}
+	return 0
+}
+
+// SetMaxDecompressedBodySize set max size of request body after decompressed
+// It is used to avoid zip bomb
+// Use NoBodyLimit to disable the limit of parent BluePrint
+func (b *BluePrint) SetMaxDecompressedBodySize(size int64) {
+	if size == 0 {
+		panic("maxDecompressedBodySize can not be zero")
+	}
+	b.maxDecompressedBodySize = size
+}
+
 // NewBluePrint constructor for BluePrint
 func NewBluePrint() *BluePrint {
 	return &BluePrint{}
@@ -331,10 +559,22 @@ func NewBluePrint() *BluePrint {
 func DefaultBluePrint() *BluePrint {
 	bp := NewBluePrint()
 	bp.SetFileStorage(&LocalFileStorage{})
-	bp.SetParsers(Parsers{JsonParser{}, FormParser{}, MultipartFormParser{}, XMLParser{}})
+	bp.SetParsers(Parsers{
+		JsonParser{}, FormParser{}, MultipartFormParser{}, XMLParser{},
+		YAMLParser{}, TOMLParser{}, MsgPackParser{}, CBORParser{}, ProtobufParser{}, CSVParser{},
+	})
 	bp.SetHTMLLoader(&TemplateLoader{})
-	bp.SetJSONSerializer(internal.JsonSerializer{})
-	bp.SetXMLSerializer(internal.XmlSerializer{})
+	bp.SetJSONSerializer(serializers.JsonSerializer{})
+	bp.SetXMLSerializer(serializers.XmlSerializer{})
+	bp.SetYAMLSerializer(serializers.YamlSerializer{})
+	bp.SetTOMLSerializer(serializers.TomlSerializer{})
+	bp.SetMsgPackSerializer(serializers.MsgPackSerializer{})
+	bp.SetCBORSerializer(serializers.CborSerializer{})
+	bp.SetProtobufSerializer(serializers.ProtobufSerializer{})
+	bp.SetBinderConfig(binders.DefaultConfig())
+	bp.SetValidator(&validators.TagValidator{})
+	bp.SetMaxBodySize(defaultMaxBodySize)
+	bp.SetMaxDecompressedBodySize(defaultMaxDecompressedBodySize)
 	return bp
 }
 
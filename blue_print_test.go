@@ -0,0 +1,106 @@
+package regia
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/eatmoreapple/regia/binders"
+)
+
+type binderConfigQuery struct {
+	Name string `form:"name" bind:"upper"`
+	Page int    `form:"page,1"`
+}
+
+type binderConfigLowerQuery struct {
+	Name string `form:"name" bind:"lower"`
+}
+
+func TestBinderConfig(t *testing.T) {
+	upper := func(value reflect.Value, values []string) error {
+		value.SetString(strings.ToUpper(values[0]))
+		return nil
+	}
+	lower := func(value reflect.Value, values []string) error {
+		value.SetString(strings.ToLower(values[0]))
+		return nil
+	}
+	handle := func(v interface{}) HandleFunc {
+		return func(c *Context) {
+			if err := c.BindQuery(v); err != nil {
+				_ = c.String(err.Error())
+				return
+			}
+			value := reflect.ValueOf(v).Elem()
+			result := value.FieldByName("Name").String()
+			if page := value.FieldByName("Page"); page.IsValid() {
+				result += ":" + strconv.FormatInt(page.Int(), 10)
+			}
+			_ = c.String(result)
+		}
+	}
+
+	engine := New()
+	if err := engine.AddBindMethod("upper", upper); err != nil {
+		t.Fatal(err)
+	}
+	if err := engine.AddBindMethod("upper", upper); err == nil {
+		t.Error("expected error for duplicated bind method")
+	}
+	engine.GET("/", handle(&binderConfigQuery{}))
+	engine.GET("/lower", handle(&binderConfigLowerQuery{}))
+
+	// inherits the config of engine
+	inherited := NewBluePrint()
+	inherited.GET("/", handle(&binderConfigQuery{}))
+	engine.Include("/inherited", inherited)
+
+	// adds its own method to a copy of inherited config
+	own := NewBluePrint()
+	own.GET("/", handle(&binderConfigQuery{}))
+	own.GET("/lower", handle(&binderConfigLowerQuery{}))
+	engine.Include("/own", own)
+	if err := own.AddBindMethod("lower", lower); err != nil {
+		t.Fatal(err)
+	}
+
+	emptyDefault := NewBluePrint()
+	emptyDefault.SetBinderConfig(&binders.Config{FormTag: "form", DefaultOnEmpty: true})
+	emptyDefault.GET("/", handle(&binderConfigQuery{}))
+	engine.Include("/empty", emptyDefault)
+
+	disabledDefault := NewBluePrint()
+	disabledDefault.SetBinderConfig(&binders.Config{FormTag: "form", DisableDefault: true})
+	disabledDefault.GET("/", handle(&binderConfigQuery{}))
+	engine.Include("/disabled", disabledDefault)
+	_ = engine.init()
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/?name=regia", "REGIA:1"},
+		{"/inherited/?name=regia&page=2", "REGIA:2"},
+		{"/own/?name=regia", "REGIA:1"},
+		{"/own/lower?name=REGIA", "regia"},
+		{"/lower?name=REGIA", "no method named lower"},
+		// the default is only used for empty value with DefaultOnEmpty
+		{"/?name=regia&page=", "REGIA:0"},
+		{"/empty/?page=", ":1"},
+		{"/disabled/", ":0"},
+	}
+	for _, test := range tests {
+		recorder := httptest.NewRecorder()
+		engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, test.path, nil))
+		if recorder.Body.String() != test.want {
+			t.Errorf("%s: expected %q, got %q", test.path, test.want, recorder.Body.String())
+		}
+	}
+	if _, exist := engine.BinderConfig().BindMethods["lower"]; exist {
+		t.Error("bind method of child should not be added to parent")
+	}
+}
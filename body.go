@@ -0,0 +1,238 @@
+// Copyright 2022 eatmoreapple.  All rights reserved.
+// Use of this source code is governed by a GPL style
+// license that can be found in the LICENSE file.
+
+package regia
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/eatmoreapple/regia/binders"
+	"github.com/eatmoreapple/regia/serializers"
+)
+
+const (
+	// NoBodyLimit disable the body size limit of BluePrint
+	NoBodyLimit = -1
+
+	defaultMaxBodySize             = 32 << 20
+	defaultMaxDecompressedBodySize = 32 << 20
+)
+
+// ErrBodyTooLarge returned when request body exceeds the limit of BluePrint
+var ErrBodyTooLarge = NewHttpError(http.StatusRequestEntityTooLarge, "request body too large")
+
+// limitedReader returns ErrBodyTooLarge after reading more than n bytes
+type limitedReader struct {
+	reader io.Reader
+	n      int64
+	// exceeded is kept even if the decoder wraps or drops ErrBodyTooLarge
+	exceeded bool
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.n < 0 {
+		return 0, ErrBodyTooLarge
+	}
+	// read one more byte to detect if the limit exceeded
+	if int64(len(p)) > l.n+1 {
+		p = p[:l.n+1]
+	}
+	n, err := l.reader.Read(p)
+	l.n -= int64(n)
+	if l.n < 0 {
+		l.exceeded = true
+		return n + int(l.n), ErrBodyTooLarge
+	}
+	return n, err
+}
+
+// requestBody combines reader with closers of the original request body
+type requestBody struct {
+	io.Reader
+	closers []io.Closer
+	limits  []*limitedReader
+}
+
+// exceeded reports whether any limit of body has been exceeded
+func (b *requestBody) exceeded() bool {
+	for _, limit := range b.limits {
+		if limit.exceeded {
+			return true
+		}
+	}
+	return false
+}
+
+// limit wraps the reader of body with limitedReader
+func (b *requestBody) limit(n int64) {
+	limit := &limitedReader{reader: b.Reader, n: n}
+	b.limits = append(b.limits, limit)
+	b.Reader = limit
+}
+
+func (b *requestBody) Close() error {
+	var err error
+	for _, closer := range b.closers {
+		if e := closer.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// prepareBody wraps the request body with size limit and decompression
+// It only works once for each request
+// If the body has been cached by Context.Body, Request.Body will be rewound
+func (c *Context) prepareBody() error {
+	if c.bodyPrepared {
+		if c.bodyCached {
+			c.rewindBody()
+		}
+		return c.bodyErr
+	}
+	c.bodyPrepared = true
+	c.bodyErr = c.wrapBody()
+	return c.bodyErr
+}
+
+func (c *Context) wrapBody() error {
+	request := c.Request
+	if request.Body == nil || request.Body == http.NoBody {
+		return nil
+	}
+	bp := c.BluePrint()
+	wrapped := &requestBody{Reader: request.Body, closers: []io.Closer{request.Body}}
+
+	if limit := bp.MaxBodySize(); limit > 0 {
+		if request.ContentLength > limit {
+			return ErrBodyTooLarge
+		}
+		wrapped.limit(limit)
+	}
+
+	encoding := strings.ToLower(strings.TrimSpace(request.Header.Get("Content-Encoding")))
+	switch encoding {
+	case "", "identity":
+	case "gzip", "x-gzip":
+		reader, err := gzip.NewReader(wrapped.Reader)
+		if err != nil {
+			return &HttpError{Code: http.StatusBadRequest, Message: "invalid gzip body", Err: err}
+		}
+		wrapped.Reader = reader
+		wrapped.closers = append(wrapped.closers, reader)
+	case "deflate":
+		reader, err := zlib.NewReader(wrapped.Reader)
+		if err != nil {
+			return &HttpError{Code: http.StatusBadRequest, Message: "invalid deflate body", Err: err}
+		}
+		wrapped.Reader = reader
+		wrapped.closers = append(wrapped.closers, reader)
+	default:
+		return NewHttpError(http.StatusUnsupportedMediaType, "unsupported content encoding "+encoding)
+	}
+
+	if encoding != "" && encoding != "identity" {
+		// avoid zip bomb
+		if limit := bp.MaxDecompressedBodySize(); limit > 0 {
+			wrapped.limit(limit)
+		}
+		// body has been decompressed
+		request.Header.Del("Content-Encoding")
+		request.ContentLength = -1
+	}
+	request.Body = wrapped
+	c.body = wrapped
+	return nil
+}
+
+// bodyError converts the error of reading or decoding request body
+// ErrBodyTooLarge is returned if the limit exceeded, whatever the decoder returned
+// Errors without status code are caused by malformed body and reply 400
+func (c *Context) bodyError(err error) error {
+	if c.body != nil && c.body.exceeded() {
+		c.bodyErr = ErrBodyTooLarge
+		return ErrBodyTooLarge
+	}
+	var coder statusCoder
+	if errors.As(err, &coder) {
+		return err
+	}
+	// the destination is invalid, it is not the fault of client
+	var unmarshalErr *json.InvalidUnmarshalError
+	if errors.As(err, &unmarshalErr) || errors.Is(err, serializers.ErrNotProtoMessage) {
+		return err
+	}
+	return &HttpError{Code: http.StatusBadRequest, Message: "invalid request body", Err: err}
+}
+
+// bindBody is like Context.Bind, but converts the errors with Context.bodyError
+func (c *Context) bindBody(binder binders.Binder, v interface{}) error {
+	if err := c.prepareBody(); err != nil {
+		return err
+	}
+	if err := binder.Bind(c.Request, v); err != nil {
+		return c.bodyError(err)
+	}
+	return c.Validate(v)
+}
+
+// Body reads the whole request body once and caches it
+// Request body is limited and decompressed according to the BluePrint,
+// the body is limited to 32MB by default, see BluePrint.SetMaxBodySize
+// Request.Body will be rewound before every binding,
+// so the body can be read by middleware, such as verifying signature, and bound by handler
+//
+//	body, err := c.Body()
+//	if err != nil {
+//		c.AbortWithError(err)
+//		return
+//	}
+//	if !verify(body, c.Request.Header.Get("X-Signature")) {
+//		c.AbortWithError(NewHttpError(http.StatusUnauthorized, ""))
+//		return
+//	}
+//	c.Next()
+func (c *Context) Body() ([]byte, error) {
+	if err := c.prepareBody(); err != nil {
+		return nil, err
+	}
+	if c.bodyCached {
+		return c.bodyCache, nil
+	}
+	request := c.Request
+	if request.Body != nil && request.Body != http.NoBody {
+		data, err := io.ReadAll(request.Body)
+		_ = request.Body.Close()
+		if err != nil {
+			c.bodyErr = c.bodyError(err)
+			return nil, c.bodyErr
+		}
+		c.bodyCache = data
+	}
+	c.bodyCached = true
+	request.ContentLength = int64(len(c.bodyCache))
+	// Context will be reused, do not read the cache of it
+	data := c.bodyCache
+	request.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+	c.rewindBody()
+	return c.bodyCache, nil
+}
+
+// rewindBody replaces Request.Body with a new reader of cached body
+func (c *Context) rewindBody() {
+	if len(c.bodyCache) == 0 {
+		c.Request.Body = http.NoBody
+		return
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(c.bodyCache))
+}
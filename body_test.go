@@ -0,0 +1,310 @@
+package regia
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/eatmoreapple/regia/serializers"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestRequestBodyLimit(t *testing.T) {
+	engine := New()
+	engine.SetMaxBodySize(40)
+	engine.SetMaxDecompressedBodySize(32)
+	engine.POST("/", func(c *Context) {
+		var v map[string]string
+		if err := c.BindJSON(&v); err != nil {
+			c.AbortWithError(err)
+			return
+		}
+		_ = c.String(v["a"])
+	})
+	_ = engine.init()
+
+	gzipped := func(s string) string {
+		var buf bytes.Buffer
+		writer := gzip.NewWriter(&buf)
+		_, _ = writer.Write([]byte(s))
+		_ = writer.Close()
+		return buf.String()
+	}
+
+	cases := []struct {
+		body     string
+		encoding string
+		code     int
+	}{
+		{`{"a":"b"}`, "", http.StatusOK},
+		{`{"a":"` + strings.Repeat("b", 64) + `"}`, "", http.StatusRequestEntityTooLarge},
+		{gzipped(`{"a":"b"}`), "gzip", http.StatusOK},
+		{gzipped(`{"a":"` + strings.Repeat("b", 64) + `"}`), "gzip", http.StatusRequestEntityTooLarge},
+		{`{"a":"b"}`, "br", http.StatusUnsupportedMediaType},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(c.body))
+		req.Header.Set("Content-Encoding", c.encoding)
+		recorder := httptest.NewRecorder()
+		engine.ServeHTTP(recorder, req)
+		if recorder.Code != c.code {
+			t.Errorf("encoding %q: got status %d, want %d", c.encoding, recorder.Code, c.code)
+		}
+	}
+}
+
+func TestContextBody(t *testing.T) {
+	engine := New()
+	engine.SetMaxBodySize(32)
+	var raw []byte
+	engine.Use(func(c *Context) {
+		body, err := c.Body()
+		if err != nil {
+			c.AbortWithError(err)
+			return
+		}
+		raw = body
+	})
+	engine.POST("/", func(c *Context) {
+		var first, second map[string]string
+		if err := c.BindJSON(&first); err != nil {
+			c.AbortWithError(err)
+			return
+		}
+		if err := c.Data(&second); err != nil {
+			c.AbortWithError(err)
+			return
+		}
+		_ = c.String(first["a"] + second["a"])
+	})
+	_ = engine.init()
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"a":"b"}`))
+	req.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, req)
+	if recorder.Code != http.StatusOK || recorder.Body.String() != "bb" || string(raw) != `{"a":"b"}` {
+		t.Errorf("got %d %q, raw %q", recorder.Code, recorder.Body.String(), raw)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"a":"`+strings.Repeat("b", 64)+`"}`))
+	recorder = httptest.NewRecorder()
+	engine.ServeHTTP(recorder, req)
+	if recorder.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("got status %d, want %d", recorder.Code, http.StatusRequestEntityTooLarge)
+	}
+
+	// the gzip body is truncated
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	_, _ = writer.Write([]byte(`{"a":"b"}`))
+	_ = writer.Close()
+	req = httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(compressed.Bytes()[:compressed.Len()-4]))
+	req.Header.Set("Content-Encoding", "gzip")
+	recorder = httptest.NewRecorder()
+	engine.ServeHTTP(recorder, req)
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("got status %d, want %d", recorder.Code, http.StatusBadRequest)
+	}
+}
+
+type bodyItem struct {
+	Name string
+}
+
+func TestBodyErrors(t *testing.T) {
+	engine := New()
+	engine.SetMaxBodySize(64)
+	engine.POST("/:kind", func(c *Context) {
+		var v interface{}
+		switch c.Params().Get("kind") {
+		case "protobuf":
+			v = &wrapperspb.StringValue{}
+		case "csv":
+			v = &[]bodyItem{}
+		default:
+			v = &bodyItem{}
+		}
+		if err := c.Data(v); err != nil {
+			c.AbortWithError(err)
+		}
+	})
+	_ = engine.init()
+
+	long := strings.Repeat("b", 128)
+	encode := func(serializer serializers.Serializer, v interface{}) string {
+		var buf bytes.Buffer
+		if err := serializer.Encode(&buf, v); err != nil {
+			t.Fatal(err)
+		}
+		return buf.String()
+	}
+	protobuf, _ := proto.Marshal(wrapperspb.String(long))
+	var multipartBody bytes.Buffer
+	writer := multipart.NewWriter(&multipartBody)
+	_ = writer.WriteField("Name", long)
+	_ = writer.Close()
+
+	tests := []struct {
+		kind        string
+		contentType string
+		large       string
+		malformed   string
+	}{
+		{"json", "application/json", encode(serializers.JsonSerializer{}, bodyItem{long}), `{"Name":`},
+		{"xml", "application/xml", encode(serializers.XmlSerializer{}, bodyItem{long}), `<a><b></a>`},
+		{"yaml", "application/yaml", encode(serializers.YamlSerializer{}, bodyItem{long}), "a: [b"},
+		{"toml", "application/toml", encode(serializers.TomlSerializer{}, bodyItem{long}), "Name = "},
+		{"msgpack", "application/msgpack", encode(serializers.MsgPackSerializer{}, bodyItem{long}), "\xc1"},
+		{"cbor", "application/cbor", encode(serializers.CborSerializer{}, bodyItem{long}), "\xff"},
+		{"protobuf", "application/x-protobuf", string(protobuf), "\x0a\x05ab"},
+		{"csv", "text/csv", "Name\n" + long + "\n", "Name\n\"a"},
+		{"form", "application/x-www-form-urlencoded", "Name=" + long, "Name=%zz"},
+		{"multipart", writer.FormDataContentType(), multipartBody.String(), "garbage"},
+	}
+	for _, test := range tests {
+		for body, code := range map[string]int{
+			test.large:     http.StatusRequestEntityTooLarge,
+			test.malformed: http.StatusBadRequest,
+		} {
+			request := httptest.NewRequest(http.MethodPost, "/"+test.kind, strings.NewReader(body))
+			request.Header.Set("Content-Type", test.contentType)
+			// unknown length, the limit is found while decoding
+			request.ContentLength = -1
+			recorder := httptest.NewRecorder()
+			engine.ServeHTTP(recorder, request)
+			if recorder.Code != code {
+				t.Errorf("%s: expected %d, got %d %s", test.kind, code, recorder.Code, recorder.Body.String())
+			}
+		}
+	}
+}
+
+func TestBindAllBodyErrors(t *testing.T) {
+	engine := New()
+	engine.SetMaxBodySize(64)
+	engine.POST("/", func(c *Context) {
+		var v bodyItem
+		if err := c.BindAll(&v); err != nil {
+			c.AbortWithError(err)
+		}
+	})
+	_ = engine.init()
+
+	long := strings.Repeat("b", 128)
+	tests := []struct {
+		contentType string
+		body        string
+		code        int
+	}{
+		{"application/json", `{"Name":"` + long + `"}`, http.StatusRequestEntityTooLarge},
+		{"application/json", `{"Name":`, http.StatusBadRequest},
+		{"application/x-www-form-urlencoded", "Name=" + long, http.StatusRequestEntityTooLarge},
+		{"application/x-www-form-urlencoded", "Name=%zz", http.StatusBadRequest},
+		{"multipart/form-data; boundary=x", "garbage", http.StatusBadRequest},
+	}
+	for _, test := range tests {
+		request := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(test.body))
+		request.Header.Set("Content-Type", test.contentType)
+		request.ContentLength = -1
+		recorder := httptest.NewRecorder()
+		engine.ServeHTTP(recorder, request)
+		if recorder.Code != test.code {
+			t.Errorf("%s %q: expected %d, got %d %s", test.contentType, test.body, test.code, recorder.Code, recorder.Body.String())
+		}
+	}
+}
+
+func TestBindMultipartStreamErrors(t *testing.T) {
+	errHandle := errors.New("handle failed")
+	engine := New()
+	engine.SetMaxBodySize(256)
+	engine.POST("/:handle", func(c *Context) {
+		var v bodyItem
+		err := c.BindMultipartStream(&v, func(part *multipart.Part) error {
+			if c.Params().Get("handle") == "fail" {
+				return errHandle
+			}
+			_, err := io.Copy(io.Discard, part)
+			return err
+		})
+		if c.Params().Get("handle") == "fail" && !errors.Is(err, errHandle) {
+			t.Errorf("expected the error of handle, got %v", err)
+		}
+		if err != nil {
+			c.AbortWithError(err)
+		}
+	})
+	_ = engine.init()
+
+	multipartBody := func(content string) (string, string) {
+		var body bytes.Buffer
+		writer := multipart.NewWriter(&body)
+		part, _ := writer.CreateFormFile("file", "a.txt")
+		_, _ = part.Write([]byte(content))
+		_ = writer.Close()
+		return writer.FormDataContentType(), body.String()
+	}
+	contentType, small := multipartBody("a")
+	largeType, large := multipartBody(strings.Repeat("b", 512))
+	tests := []struct {
+		path        string
+		contentType string
+		body        string
+		code        int
+	}{
+		{"/ok", contentType, small, http.StatusOK},
+		{"/ok", largeType, large, http.StatusRequestEntityTooLarge},
+		{"/ok", contentType, "garbage", http.StatusBadRequest},
+		{"/fail", contentType, small, http.StatusInternalServerError},
+	}
+	for _, test := range tests {
+		request := httptest.NewRequest(http.MethodPost, test.path, strings.NewReader(test.body))
+		request.Header.Set("Content-Type", test.contentType)
+		request.ContentLength = -1
+		recorder := httptest.NewRecorder()
+		engine.ServeHTTP(recorder, request)
+		if recorder.Code != test.code {
+			t.Errorf("%s: expected %d, got %d %s", test.path, test.code, recorder.Code, recorder.Body.String())
+		}
+	}
+}
+
+func TestContextBodyGetBody(t *testing.T) {
+	engine := New()
+	if engine.MaxBodySize() != defaultMaxBodySize {
+		t.Errorf("expected default limit %d, got %d", defaultMaxBodySize, engine.MaxBodySize())
+	}
+	var requests []*http.Request
+	engine.POST("/", func(c *Context) {
+		if _, err := c.Body(); err != nil {
+			c.AbortWithError(err)
+			return
+		}
+		requests = append(requests, c.Request)
+	})
+	_ = engine.init()
+
+	// the second request reuses the Context of first one
+	for _, body := range []string{"first", "second"} {
+		engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+	}
+	for i, want := range []string{"first", "second"} {
+		reader, err := requests[i].GetBody()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		_, _ = buf.ReadFrom(reader)
+		if buf.String() != want {
+			t.Errorf("expected %q, got %q", want, buf.String())
+		}
+	}
+}
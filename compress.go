@@ -0,0 +1,383 @@
+// Copyright 2022 eatmoreapple.  All rights reserved.
+// Use of this source code is governed by a GPL style
+// license that can be found in the LICENSE file.
+
+package regia
+
+import (
+	"bufio"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	EncodingBrotli  = "br"
+	EncodingZstd    = "zstd"
+	EncodingGzip    = "gzip"
+	EncodingDeflate = "deflate"
+
+	defaultCompressMinLength = 1024
+)
+
+// defaultCompressEncodings are offered in order of preference
+var defaultCompressEncodings = []string{EncodingBrotli, EncodingZstd, EncodingGzip, EncodingDeflate}
+
+// defaultCompressExcludedTypes are already compressed
+var defaultCompressExcludedTypes = []string{
+	"image/png", "image/jpeg", "image/gif", "image/webp", "image/avif", "image/heic",
+	"video/*", "audio/*", "font/woff", "font/woff2",
+	"application/zip", "application/gzip", "application/x-gzip", "application/zstd",
+	"application/x-brotli", "application/x-bzip2", "application/x-xz",
+	"application/x-7z-compressed", "application/x-rar-compressed", "application/pdf",
+}
+
+// CompressConfig is the config of Compress
+type CompressConfig struct {
+	// Encodings are offered in order of preference when the client accepts them equally
+	// Default br, zstd, gzip and deflate
+	Encodings []string
+	// GzipLevel is the level of gzip and deflate, 0 uses gzip.DefaultCompression
+	GzipLevel int
+	// BrotliLevel is the quality of brotli, 0 uses brotli.DefaultCompression
+	BrotliLevel int
+	// ZstdLevel is the level of zstd, 0 uses zstd.SpeedDefault
+	ZstdLevel int
+	// MinLength is the min length of body to compress, default 1024
+	// The body shorter than it is written as is
+	MinLength int
+	// ExcludedTypes are the content types not compressed, such as image/png and video/*
+	// Default are common types which have been compressed
+	ExcludedTypes []string
+	// Skip reports whether the response should not be compressed
+	Skip func(c *Context) bool
+}
+
+// compressor is implemented by writers of all encodings
+type compressor interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// Compress returns a middleware which compresses the response with the encoding negotiated by Accept-Encoding
+// The response is not compressed if
+// its body is shorter than MinLength, its content type is excluded,
+// it has been encoded or ranged, the status has no body, or the request method is HEAD
+//
+//	engine.AddInterceptors(regia.Compress(regia.CompressConfig{}))
+func Compress(config CompressConfig) HandleFunc {
+	if len(config.Encodings) == 0 {
+		config.Encodings = defaultCompressEncodings
+	}
+	if config.MinLength <= 0 {
+		config.MinLength = defaultCompressMinLength
+	}
+	if config.ExcludedTypes == nil {
+		config.ExcludedTypes = defaultCompressExcludedTypes
+	}
+	pools := make(map[string]*sync.Pool, len(config.Encodings))
+	for _, encoding := range config.Encodings {
+		newCompressor := compressorFactory(encoding, config)
+		if newCompressor == nil {
+			panic("unsupported encoding: " + encoding)
+		}
+		pools[encoding] = &sync.Pool{New: func() interface{} { return newCompressor() }}
+	}
+	excluded := make(map[string]struct{}, len(config.ExcludedTypes))
+	for _, contentType := range config.ExcludedTypes {
+		excluded[strings.ToLower(contentType)] = struct{}{}
+	}
+
+	return func(c *Context) {
+		if config.Skip != nil && config.Skip(c) {
+			return
+		}
+		addVary(c.ResponseWriter.Header(), "Accept-Encoding")
+		encoding := negotiateEncoding(c.Request.Header.Get("Accept-Encoding"), config.Encodings)
+		if len(encoding) == 0 || c.Request.Method == http.MethodHead {
+			return
+		}
+		writer := &compressWriter{
+			ResponseWriter: c.ResponseWriter,
+			encoding:       encoding,
+			pool:           pools[encoding],
+			minLength:      config.MinLength,
+			excluded:       excluded,
+		}
+		c.ResponseWriter = writer
+		defer func() {
+			writer.close()
+			// Context.finish writes the status to the original writer if nothing written
+			c.ResponseWriter = writer.ResponseWriter
+		}()
+		c.Next()
+	}
+}
+
+// Gzip is a middleware for gzip compression
+// it will compress the response body if the client accepts gzip encoding
+// param is the compression level, choose from gzip.BestSpeed to gzip.BestCompression
+func Gzip(level int) HandleFunc {
+	return Compress(CompressConfig{Encodings: []string{EncodingGzip}, GzipLevel: level})
+}
+
+func compressorFactory(encoding string, config CompressConfig) func() compressor {
+	switch encoding {
+	case EncodingGzip:
+		level := config.GzipLevel
+		if level == 0 {
+			level = gzip.DefaultCompression
+		}
+		if _, err := gzip.NewWriterLevel(nil, level); err != nil {
+			panic(err)
+		}
+		return func() compressor {
+			writer, _ := gzip.NewWriterLevel(nil, level)
+			return writer
+		}
+	case EncodingDeflate:
+		level := config.GzipLevel
+		if level == 0 {
+			level = zlib.DefaultCompression
+		}
+		if _, err := zlib.NewWriterLevel(nil, level); err != nil {
+			panic(err)
+		}
+		return func() compressor {
+			writer, _ := zlib.NewWriterLevel(nil, level)
+			return writer
+		}
+	case EncodingBrotli:
+		level := config.BrotliLevel
+		if level == 0 {
+			level = brotli.DefaultCompression
+		}
+		return func() compressor { return brotli.NewWriterLevel(nil, level) }
+	case EncodingZstd:
+		level := zstd.SpeedDefault
+		if config.ZstdLevel != 0 {
+			level = zstd.EncoderLevelFromZstd(config.ZstdLevel)
+		}
+		return func() compressor {
+			writer, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(level), zstd.WithEncoderConcurrency(1))
+			if err != nil {
+				panic(err)
+			}
+			return writer
+		}
+	}
+	return nil
+}
+
+// negotiateEncoding returns the offer with the highest q-value of Accept-Encoding header
+// Offers with the same q-value are chosen by their order
+// Empty string returned if nothing acceptable or identity is preferred
+func negotiateEncoding(header string, offers []string) string {
+	if len(header) == 0 {
+		return ""
+	}
+	specs := parseAccept(header)
+	qOf := func(encoding string) float64 {
+		wildcard := -1.0
+		for _, spec := range specs {
+			if spec.value == encoding {
+				return spec.q
+			}
+			if spec.value == "*" && wildcard < 0 {
+				wildcard = spec.q
+			}
+		}
+		if encoding == "identity" && wildcard < 0 {
+			// identity is acceptable unless refused
+			return 0.001
+		}
+		return wildcard
+	}
+	var chosen string
+	var best float64
+	for _, offer := range offers {
+		if q := qOf(offer); q > best {
+			chosen, best = offer, q
+		}
+	}
+	if qOf("identity") > best {
+		return ""
+	}
+	return chosen
+}
+
+// compressWriter buffers the body until MinLength to decide whether to compress
+type compressWriter struct {
+	http.ResponseWriter
+	encoding  string
+	pool      *sync.Pool
+	minLength int
+	excluded  map[string]struct{}
+
+	writer      compressor
+	buf         []byte
+	status      int
+	wroteHeader bool
+	decided     bool
+}
+
+// WriteHeader delays the status until the body decides whether to compress
+func (w *compressWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	// informational responses are sent as is
+	if code >= 100 && code < 200 && code != http.StatusSwitchingProtocols {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	w.status = code
+	w.wroteHeader = true
+	if !w.compressible() {
+		_ = w.decide(false)
+	}
+}
+
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.decided {
+		if w.writer != nil {
+			return w.writer.Write(b)
+		}
+		return w.ResponseWriter.Write(b)
+	}
+	w.buf = append(w.buf, b...)
+	if len(w.buf) >= w.minLength {
+		if err := w.decide(w.compressible()); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
+
+// Flush implements http.Flusher
+// The buffered body is written before flushing, compressed if it reaches MinLength
+func (w *compressWriter) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if !w.decided {
+		_ = w.decide(len(w.buf) >= w.minLength && w.compressible())
+	}
+	if w.writer != nil {
+		_ = w.writer.Flush()
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker
+func (w *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if w.writer != nil {
+		return nil, nil, errors.New("response has been compressed")
+	}
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("http.Hijacker not implemented")
+	}
+	// nothing will be written by http.ResponseWriter after hijacked
+	w.wroteHeader, w.decided = true, true
+	return hijacker.Hijack()
+}
+
+// Unwrap returns the original http.ResponseWriter for http.ResponseController
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// compressible reports whether the response could be compressed regardless of the length of body
+func (w *compressWriter) compressible() bool {
+	switch {
+	case w.status < http.StatusOK, w.status == http.StatusNoContent,
+		w.status == http.StatusNotModified, w.status == http.StatusPartialContent:
+		return false
+	}
+	header := w.Header()
+	if len(header.Get("Content-Encoding")) > 0 || len(header.Get("Content-Range")) > 0 {
+		return false
+	}
+	contentType := header.Get("Content-Type")
+	if len(contentType) == 0 {
+		if len(w.buf) == 0 {
+			return true
+		}
+		contentType = http.DetectContentType(w.buf)
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return true
+	}
+	if _, ok := w.excluded[mediaType]; ok {
+		return false
+	}
+	if index := strings.IndexByte(mediaType, '/'); index > 0 {
+		if _, ok := w.excluded[mediaType[:index]+"/*"]; ok {
+			return false
+		}
+	}
+	return true
+}
+
+// decide writes the header and the buffered body, compressed or not
+func (w *compressWriter) decide(compress bool) error {
+	w.decided = true
+	header := w.Header()
+	if compress {
+		// sniff before compressed, or net/http would sniff the compressed body
+		if len(header.Get("Content-Type")) == 0 {
+			header.Set("Content-Type", http.DetectContentType(w.buf))
+		}
+		header.Set("Content-Encoding", w.encoding)
+		header.Del("Content-Length")
+		// the compressed representation is not byte-for-byte identical
+		if etag := header.Get("ETag"); len(etag) > 0 && !strings.HasPrefix(etag, "W/") {
+			header.Set("ETag", "W/"+etag)
+		}
+		w.writer = w.pool.Get().(compressor)
+		w.writer.Reset(w.ResponseWriter)
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	if len(w.buf) == 0 {
+		return nil
+	}
+	var err error
+	if w.writer != nil {
+		_, err = w.writer.Write(w.buf)
+	} else {
+		_, err = w.ResponseWriter.Write(w.buf)
+	}
+	w.buf = nil
+	return err
+}
+
+// close writes the rest of response and puts the compressor back to pool
+func (w *compressWriter) close() {
+	if !w.decided && w.wroteHeader {
+		_ = w.decide(len(w.buf) >= w.minLength && w.compressible())
+	}
+	if w.writer == nil {
+		return
+	}
+	_ = w.writer.Close()
+	w.writer.Reset(nil)
+	w.pool.Put(w.writer)
+	w.writer = nil
+}
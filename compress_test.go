@@ -0,0 +1,117 @@
+package regia
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	offers := defaultCompressEncodings
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", ""},
+		{"gzip", EncodingGzip},
+		{"gzip, deflate, br, zstd", EncodingBrotli},
+		{"gzip;q=1, br;q=0.5", EncodingGzip},
+		{"br;q=0, *", EncodingZstd},
+		{"identity", ""},
+		{"gzip;q=0.5, identity", ""},
+		{"*;q=0", ""},
+		{"compress", ""},
+	}
+	for _, test := range tests {
+		if got := negotiateEncoding(test.header, offers); got != test.want {
+			t.Errorf("%q: expected %q, got %q", test.header, test.want, got)
+		}
+	}
+}
+
+func TestCompress(t *testing.T) {
+	body := strings.Repeat("regia ", 1024)
+	engine := New()
+	engine.AddInterceptors(Compress(CompressConfig{}))
+	engine.GET("/text", func(c *Context) { _ = c.String(body) })
+	engine.HEAD("/text", func(c *Context) { _ = c.String(body) })
+	engine.GET("/small", func(c *Context) { _ = c.String("small") })
+	engine.GET("/png", func(c *Context) {
+		c.SetHeader("Content-Type", "image/png")
+		_, _ = c.ResponseWriter.Write([]byte(body))
+	})
+	engine.GET("/empty", func(c *Context) { c.SetStatus(http.StatusNoContent) })
+	engine.GET("/stream", func(c *Context) {
+		for i := 0; i < 3; i++ {
+			_, _ = c.ResponseWriter.Write([]byte(body))
+			c.ResponseWriter.(http.Flusher).Flush()
+		}
+	})
+	_ = engine.init()
+
+	decoders := map[string]func(io.Reader) (io.Reader, error){
+		EncodingGzip: func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+		EncodingDeflate: func(r io.Reader) (io.Reader, error) {
+			return zlib.NewReader(r)
+		},
+		EncodingBrotli: func(r io.Reader) (io.Reader, error) { return brotli.NewReader(r), nil },
+		EncodingZstd: func(r io.Reader) (io.Reader, error) {
+			decoder, err := zstd.NewReader(r)
+			return decoder, err
+		},
+	}
+	for encoding, decode := range decoders {
+		for _, path := range []string{"/text", "/stream"} {
+			recorder := httptest.NewRecorder()
+			request := httptest.NewRequest(http.MethodGet, path, nil)
+			request.Header.Set("Accept-Encoding", encoding)
+			engine.ServeHTTP(recorder, request)
+			if got := recorder.Header().Get("Content-Encoding"); got != encoding {
+				t.Fatalf("%s %s: expected Content-Encoding %s, got %q", path, encoding, encoding, got)
+			}
+			if recorder.Header().Get("Vary") != "Accept-Encoding" || len(recorder.Header().Get("Content-Length")) > 0 {
+				t.Errorf("%s %s: unexpected header %v", path, encoding, recorder.Header())
+			}
+			reader, err := decode(recorder.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := io.ReadAll(reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := body
+			if path == "/stream" {
+				want = strings.Repeat(body, 3)
+			}
+			if string(data) != want {
+				t.Errorf("%s %s: body mismatch, got %d bytes", path, encoding, len(data))
+			}
+		}
+	}
+
+	for _, test := range []struct {
+		method, path string
+		code         int
+	}{
+		{http.MethodGet, "/small", http.StatusOK},
+		{http.MethodGet, "/png", http.StatusOK},
+		{http.MethodGet, "/empty", http.StatusNoContent},
+		{http.MethodHead, "/text", http.StatusOK},
+	} {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(test.method, test.path, nil)
+		request.Header.Set("Accept-Encoding", "gzip")
+		engine.ServeHTTP(recorder, request)
+		if recorder.Code != test.code || len(recorder.Header().Get("Content-Encoding")) > 0 {
+			t.Errorf("%s %s: should not be compressed, got %d %v", test.method, test.path, recorder.Code, recorder.Header())
+		}
+	}
+}
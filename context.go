@@ -7,7 +7,9 @@ package regia
 import (
 	"context"
 	"errors"
+	"html/template"
 	"io"
+	"mime/multipart"
 	"net"
 	"net/http"
 	"net/url"
@@ -19,7 +21,10 @@ import (
 	"github.com/eatmoreapple/regia/renders"
 )
 
-const defaultMultipartMemory = 32 << 20
+const (
+	defaultMultipartMemory  = 32 << 20
+	defaultSecureJSONPrefix = "while(1);"
+)
 
 type Context struct {
 	// if url is matched
@@ -33,7 +38,19 @@ type Context struct {
 	// query cache
 	queryCache url.Values
 	// form cache
-	formCache      url.Values
+	formCache url.Values
+	// request body has been wrapped by Context.prepareBody
+	bodyPrepared bool
+	bodyErr      error
+	// body is the request body wrapped by Context.prepareBody
+	body *requestBody
+	// request body cached by Context.Body
+	bodyCache  []byte
+	bodyCached bool
+	// templateFuncs are added to templates rendered by Context.HTML
+	templateFuncs template.FuncMap
+	// presence records the fields found by binding
+	presence       *binders.Presence
 	items          map[string]interface{}
 	lock           sync.RWMutex
 	engine         *Engine
@@ -53,6 +70,17 @@ func (c *Context) reset() {
 	c.status = 0
 	c.written = false
 	c.abortIndex = 0
+	c.bodyPrepared = false
+	c.bodyErr = nil
+	c.body = nil
+	c.bodyCache = nil
+	c.bodyCached = false
+	c.presence = nil
+	c.templateFuncs = nil
+	// values such as the user and session of previous request must not be seen by the next one
+	c.lock.Lock()
+	c.items = nil
+	c.lock.Unlock()
 }
 
 // start to handle current request
@@ -66,6 +94,11 @@ func (c *Context) finish() {
 	if c.status != 0 && !c.written {
 		c.ResponseWriter.WriteHeader(c.status)
 	}
+	// remove temporary files of upload files
+	// escaped context is still in use, so leave the files alone
+	if !c.escape && c.Request.MultipartForm != nil {
+		_ = c.Request.MultipartForm.RemoveAll()
+	}
 }
 
 // IsMatched return that route matched
@@ -98,6 +131,9 @@ func (c *Context) SaveUploadFileWith(fs FileStorage, name string) (string, error
 	if fs == nil {
 		return "", errors.New("`FileStorage` can be nil type")
 	}
+	if err := c.prepareBody(); err != nil {
+		return "", err
+	}
 	file, fileHeader, err := c.Request.FormFile(name)
 	if err != nil {
 		return "", err
@@ -141,6 +177,7 @@ func (c *Context) QueryValues(key string) []string {
 // but value for current context
 func (c *Context) Form() url.Values {
 	if c.formCache == nil {
+		_ = c.prepareBody()
 		_ = c.Request.ParseForm()
 		c.formCache = c.Request.PostForm
 	}
@@ -167,62 +204,215 @@ func (c *Context) ContentType() string {
 	return c.Request.Header.Get("Content-Type")
 }
 
-// Bind bind request to destination
+// Bind bind request to destination and validate it
+// Request body will be limited and decompressed according to the BluePrint
 func (c *Context) Bind(binder binders.Binder, v interface{}) error {
-	return binder.Bind(c.Request, v)
+	if err := c.prepareBody(); err != nil {
+		return err
+	}
+	if err := binder.Bind(c.Request, v); err != nil {
+		return err
+	}
+	return c.Validate(v)
+}
+
+// Validate validates v with the Validator of current BluePrint
+// validators.ValidationErrors will be returned if v is invalid
+func (c *Context) Validate(v interface{}) error {
+	if validator := c.BluePrint().Validator(); validator != nil {
+		return validator.Validate(v)
+	}
+	return nil
 }
 
 // BindQuery bind Query to destination
 func (c *Context) BindQuery(v interface{}) error {
-	binder := binders.QueryBinder{}
+	binder := binders.QueryBinder{Config: c.BluePrint().BinderConfig(), Presence: c.Presence()}
 	return c.Bind(binder, v)
 }
 
 // BindForm bind PostForm to destination
 func (c *Context) BindForm(v interface{}) error {
-	if err := c.Request.ParseForm(); err != nil {
+	if err := c.prepareBody(); err != nil {
 		return err
 	}
-	binder := binders.FormBinder{}
+	if err := c.Request.ParseForm(); err != nil {
+		return c.bodyError(err)
+	}
+	binder := binders.FormBinder{Config: c.BluePrint().BinderConfig(), Presence: c.Presence()}
 	return c.Bind(binder, v)
 }
 
 // BindMultipartForm bind MultipartForm to destination
 func (c *Context) BindMultipartForm(v interface{}) error {
-	if err := c.Request.ParseMultipartForm(c.engine.MultipartMemory); err != nil {
+	if err := c.prepareBody(); err != nil {
 		return err
 	}
-	binder := binders.MultipartFormBodyBinder{}
+	if err := c.Request.ParseMultipartForm(c.engine.MultipartMemory); err != nil {
+		return c.bodyError(err)
+	}
+	binder := binders.MultipartFormBodyBinder{Config: c.BluePrint().BinderConfig(), Presence: c.Presence()}
 	return c.Bind(binder, v)
 }
 
+// MultipartReader returns a reader to read multipart body part by part
+// It is useful for huge uploads which should not be buffered by ParseMultipartForm
+// It can not be used together with BindMultipartForm, FormFile and SaveUploadFile
+func (c *Context) MultipartReader() (*multipart.Reader, error) {
+	if err := c.prepareBody(); err != nil {
+		return nil, err
+	}
+	return c.Request.MultipartReader()
+}
+
+// BindMultipartStream bind the form values of multipart body to destination
+// and stream every file part to handle without buffering
+// Form values are bound after all parts have been read
+// Engine.MultipartMemory limits the total size of form values
+func (c *Context) BindMultipartStream(v interface{}, handle func(part *multipart.Part) error) error {
+	binder := binders.MultipartStreamBinder{
+		MaxMemory: c.engine.MultipartMemory,
+		Config:    c.BluePrint().BinderConfig(),
+		Presence:  c.Presence(),
+	}
+	var handleErr error
+	if handle != nil {
+		binder.Handle = func(part *multipart.Part) error {
+			handleErr = handle(part)
+			return handleErr
+		}
+	}
+	err := c.bindBody(binder, v)
+	// the error of handle is not caused by malformed body, return it unless the limit exceeded
+	if handleErr != nil && err != ErrBodyTooLarge {
+		return handleErr
+	}
+	return err
+}
+
 // BindJSON bind the request body according to the format of json
 func (c *Context) BindJSON(v interface{}) error {
 	serializer := c.BluePrint().JSONSerializer()
-	binder := binders.JsonBodyBinder{Serializer: serializer}
-	return c.Bind(binder, v)
+	binder := binders.JsonBodyBinder{Serializer: serializer, Presence: c.Presence()}
+	return c.bindBody(binder, v)
 }
 
 // BindXML bind the request body according to the format of xml
 func (c *Context) BindXML(v interface{}) error {
 	serializer := c.BluePrint().XMLSerializer()
 	binder := binders.XmlBodyBinder{Serializer: serializer}
-	return c.Bind(binder, v)
+	return c.bindBody(binder, v)
+}
+
+// BindYAML bind the request body according to the format of yaml
+func (c *Context) BindYAML(v interface{}) error {
+	serializer := c.BluePrint().YAMLSerializer()
+	binder := binders.YamlBodyBinder{Serializer: serializer}
+	return c.bindBody(binder, v)
+}
+
+// BindTOML bind the request body according to the format of toml
+func (c *Context) BindTOML(v interface{}) error {
+	serializer := c.BluePrint().TOMLSerializer()
+	binder := binders.TomlBodyBinder{Serializer: serializer}
+	return c.bindBody(binder, v)
+}
+
+// BindMsgPack bind the request body according to the format of msgpack
+func (c *Context) BindMsgPack(v interface{}) error {
+	serializer := c.BluePrint().MsgPackSerializer()
+	binder := binders.MsgPackBodyBinder{Serializer: serializer}
+	return c.bindBody(binder, v)
+}
+
+// BindCBOR bind the request body according to the format of cbor
+func (c *Context) BindCBOR(v interface{}) error {
+	serializer := c.BluePrint().CBORSerializer()
+	binder := binders.CborBodyBinder{Serializer: serializer}
+	return c.bindBody(binder, v)
+}
+
+// BindProtobuf bind the request body according to the format of protobuf
+// v must implement proto.Message
+func (c *Context) BindProtobuf(v interface{}) error {
+	serializer := c.BluePrint().ProtobufSerializer()
+	binder := binders.ProtobufBodyBinder{Serializer: serializer}
+	return c.bindBody(binder, v)
+}
+
+// BindCSV bind the csv request body to a slice of structs
+func (c *Context) BindCSV(v interface{}) error {
+	binder := binders.CSVBodyBinder{}
+	return c.bindBody(binder, v)
 }
 
 // BindHeader bind the request header to destination
 func (c *Context) BindHeader(v interface{}) error {
-	binder := binders.HeaderBinder{}
+	binder := binders.HeaderBinder{Config: c.BluePrint().BinderConfig(), Presence: c.Presence()}
 	return c.Bind(binder, v)
 }
 
 // BindURI bind the request uri to destination
 func (c *Context) BindURI(v interface{}) error {
 	values := c.params.ToURLValues()
-	binder := binders.URIBinder{Values: values}
+	binder := binders.URIBinder{Values: values, Config: c.BluePrint().BinderConfig(), Presence: c.Presence()}
+	return c.Bind(binder, v)
+}
+
+// BindCookie bind the request cookies to destination
+func (c *Context) BindCookie(v interface{}) error {
+	binder := binders.CookieBinder{Config: c.BluePrint().BinderConfig(), Presence: c.Presence()}
 	return c.Bind(binder, v)
 }
 
+// BindAll bind the fields of destination from the source named by their tags in one pass
+// The json body is decoded first, then values of form, cookie, header, query and uri
+// are bound in order, the later one has higher precedence
+// See binders.MultiSourceBinder for more details
+func (c *Context) BindAll(v interface{}) error {
+	if err := c.prepareBody(); err != nil {
+		return err
+	}
+	binder := binders.MultiSourceBinder{
+		URIValues: c.params.ToURLValues(),
+		Config:    c.BluePrint().BinderConfig(),
+		Presence:  c.Presence(),
+	}
+	contentType := strings.ToLower(c.ContentType())
+	switch {
+	case strings.Contains(contentType, mimeJson):
+		binder.Body = binders.JsonBodyBinder{Serializer: c.BluePrint().JSONSerializer(), Presence: binder.Presence}
+	case strings.Contains(contentType, mimeMultipartPostForm):
+		if err := c.Request.ParseMultipartForm(c.engine.MultipartMemory); err != nil {
+			return c.bodyError(err)
+		}
+	case strings.Contains(contentType, minePostForm):
+		if err := c.Request.ParseForm(); err != nil {
+			return c.bodyError(err)
+		}
+	}
+	return c.bindBody(binder, v)
+}
+
+// Presence returns the fields found by binding of current request
+// Fields found by every Bind method will be recorded
+func (c *Context) Presence() *binders.Presence {
+	if c.presence == nil {
+		c.presence = binders.NewPresence()
+	}
+	return c.presence
+}
+
+// Present reports whether the field of destination is found in the request by binding
+// It is useful to tell missing from zero value, such as partial update
+//
+//	if c.Present("User.Name") {
+//		user.Name = v.User.Name
+//	}
+func (c *Context) Present(field string) bool {
+	return c.presence.Present(field)
+}
+
 // GetValue get value from context
 func (c *Context) GetValue(key string) (value interface{}, exist bool) {
 	c.lock.RLock()
@@ -271,6 +461,49 @@ func (c *Context) SetCookie(cookie *http.Cookie) {
 	http.SetCookie(c.ResponseWriter, cookie)
 }
 
+// SetSignedCookie set cookie with value signed by the keys of Engine.SetCookieKeys
+// The value is still readable by client, but can not be changed
+func (c *Context) SetSignedCookie(cookie *http.Cookie) {
+	signed := *cookie
+	signed.Value = c.engine.cookies().signer.Sign(cookie.Name, []byte(cookie.Value))
+	c.SetCookie(&signed)
+}
+
+// SignedCookie returns the value of cookie set by Context.SetSignedCookie
+// Returns http.ErrNoCookie if not found and ErrCookieInvalid if the signature does not match
+func (c *Context) SignedCookie(name string) (string, error) {
+	cookie, err := c.Request.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+	value, ok := c.engine.cookies().signer.Verify(name, cookie.Value)
+	if !ok {
+		return "", ErrCookieInvalid
+	}
+	return string(value), nil
+}
+
+// SetEncryptedCookie set cookie with value encrypted by the keys of Engine.SetCookieKeys
+func (c *Context) SetEncryptedCookie(cookie *http.Cookie) {
+	encrypted := *cookie
+	encrypted.Value = c.engine.cookies().cipher.Encrypt(cookie.Name, []byte(cookie.Value))
+	c.SetCookie(&encrypted)
+}
+
+// EncryptedCookie returns the value of cookie set by Context.SetEncryptedCookie
+// Returns http.ErrNoCookie if not found and ErrCookieInvalid if it can not be decrypted
+func (c *Context) EncryptedCookie(name string) (string, error) {
+	cookie, err := c.Request.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+	value, ok := c.engine.cookies().cipher.Decrypt(name, cookie.Value)
+	if !ok {
+		return "", ErrCookieInvalid
+	}
+	return string(value), nil
+}
+
 //************************
 //*** Response Renders ***
 //************************
@@ -292,6 +525,39 @@ func (c *Context) JSON(data interface{}) error {
 	return c.Render(render, data)
 }
 
+// IndentedJSON write pretty json response
+func (c *Context) IndentedJSON(data interface{}) error {
+	serializer := c.BluePrint().JSONSerializer()
+	render := renders.IndentedJsonRender{Serializer: serializer, Indent: "    "}
+	return c.Render(render, data)
+}
+
+// AsciiJSON write json response with non-ASCII characters escaped
+func (c *Context) AsciiJSON(data interface{}) error {
+	serializer := c.BluePrint().JSONSerializer()
+	render := renders.AsciiJsonRender{Serializer: serializer}
+	return c.Render(render, data)
+}
+
+// SecureJSON write json response prefixed with Engine.SecureJSONPrefix
+// to defeat json hijacking
+func (c *Context) SecureJSON(data interface{}) error {
+	serializer := c.BluePrint().JSONSerializer()
+	render := renders.SecureJsonRender{Serializer: serializer, Prefix: c.engine.SecureJSONPrefix}
+	return c.Render(render, data)
+}
+
+// JSONP write json response wrapped with given callback
+// renders.ErrInvalidJSONPCallback will be returned if callback is not a valid javascript identifier
+func (c *Context) JSONP(callback string, data interface{}) error {
+	if !renders.ValidJSONPCallback(callback) {
+		return renders.ErrInvalidJSONPCallback
+	}
+	serializer := c.BluePrint().JSONSerializer()
+	render := renders.JsonpRender{Serializer: serializer, Callback: callback}
+	return c.Render(render, data)
+}
+
 // XML write xml response
 func (c *Context) XML(data interface{}) error {
 	serializer := c.BluePrint().XMLSerializer()
@@ -299,6 +565,50 @@ func (c *Context) XML(data interface{}) error {
 	return c.Render(render, data)
 }
 
+// YAML write yaml response
+func (c *Context) YAML(data interface{}) error {
+	serializer := c.BluePrint().YAMLSerializer()
+	render := renders.YamlRender{Serializer: serializer}
+	return c.Render(render, data)
+}
+
+// TOML write toml response
+func (c *Context) TOML(data interface{}) error {
+	serializer := c.BluePrint().TOMLSerializer()
+	render := renders.TomlRender{Serializer: serializer}
+	return c.Render(render, data)
+}
+
+// MsgPack write msgpack response
+func (c *Context) MsgPack(data interface{}) error {
+	serializer := c.BluePrint().MsgPackSerializer()
+	render := renders.MsgPackRender{Serializer: serializer}
+	return c.Render(render, data)
+}
+
+// CBOR write cbor response
+func (c *Context) CBOR(data interface{}) error {
+	serializer := c.BluePrint().CBORSerializer()
+	render := renders.CborRender{Serializer: serializer}
+	return c.Render(render, data)
+}
+
+// Protobuf write protobuf response
+// data must implement proto.Message
+func (c *Context) Protobuf(data interface{}) error {
+	serializer := c.BluePrint().ProtobufSerializer()
+	render := renders.ProtobufRender{Serializer: serializer}
+	return c.Render(render, data)
+}
+
+// CSV write csv response as attachment with given filename
+// data should be a slice, array or chan of structs
+// Use Context.Render with renders.CSV for more options
+func (c *Context) CSV(filename string, data interface{}) error {
+	render := renders.CSV{Filename: filename}
+	return c.Render(render, data)
+}
+
 // String write string response
 func (c *Context) String(format string, data ...interface{}) (err error) {
 	render := renders.StringRender{Format: format, Data: data}
@@ -306,14 +616,31 @@ func (c *Context) String(format string, data ...interface{}) (err error) {
 }
 
 // HTML write html response
+// Funcs set by Context.SetTemplateFunc are available if HTMLLoader implements FuncsHTMLLoader
 func (c *Context) HTML(name string, data interface{}) error {
-	render, err := c.BluePrint().HTMLLoader().Load(name)
+	loader := c.BluePrint().HTMLLoader()
+	var render renders.Render
+	var err error
+	if funcsLoader, ok := loader.(FuncsHTMLLoader); ok && len(c.templateFuncs) > 0 {
+		render, err = funcsLoader.LoadWithFuncs(name, c.templateFuncs)
+	} else {
+		render, err = loader.Load(name)
+	}
 	if err != nil {
 		return err
 	}
 	return c.Render(render, data)
 }
 
+// SetTemplateFunc set func for templates rendered by Context.HTML of current request
+// The name should be defined in TemplateLoader.FuncMap before parsing
+func (c *Context) SetTemplateFunc(name string, fn interface{}) {
+	if c.templateFuncs == nil {
+		c.templateFuncs = make(template.FuncMap)
+	}
+	c.templateFuncs[name] = fn
+}
+
 // Redirect Shortcut for http.Redirect
 func (c *Context) Redirect(code int, url string) error {
 	render := renders.RedirectRender{Code: code, RedirectURL: url, Request: c.Request}
@@ -339,6 +666,8 @@ func (c *Context) Write(data []byte) error {
 }
 
 // Escape can let context not return to the pool
+// Temporary files of upload files will not be removed after escaped,
+// call Request.MultipartForm.RemoveAll when finished
 func (c *Context) Escape() {
 	c.escape = true
 }
@@ -387,6 +716,12 @@ func (c *Context) AbortWithString(text string, data ...interface{}) {
 	c.Abort()
 }
 
+// AbortWithError reply with Engine.ErrorHandle and exit
+func (c *Context) AbortWithError(err error) {
+	c.engine.ErrorHandle(c, err)
+	c.Abort()
+}
+
 // AbortWithStatus set response status and exit
 func (c *Context) AbortWithStatus(code int) {
 	c.SetStatus(code)
@@ -0,0 +1,62 @@
+// Copyright 2022 eatmoreapple.  All rights reserved.
+// Use of this source code is governed by a GPL style
+// license that can be found in the LICENSE file.
+
+package regia
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"net/http"
+
+	"github.com/eatmoreapple/regia/internal"
+)
+
+const (
+	signedCookiePurpose    = "regia.signed_cookie"
+	encryptedCookiePurpose = "regia.encrypted_cookie"
+)
+
+// ErrCookieInvalid returned when the signed or encrypted cookie can not be verified
+var ErrCookieInvalid = NewHttpError(http.StatusBadRequest, "cookie invalid")
+
+// cookieCodec signs and encrypts cookies with keys of Engine
+type cookieCodec struct {
+	signer *internal.Signer
+	cipher *internal.Cipher
+}
+
+// SetCookieKeys set the keys used by Context.SetSignedCookie and Context.SetEncryptedCookie
+// The first key signs and encrypts, all keys verify and decrypt, so old keys can be kept for rotation
+// Keys can be any length, use at least 32 random bytes
+func (e *Engine) SetCookieKeys(keys ...[]byte) {
+	if len(keys) == 0 {
+		panic("cookie keys can not be empty")
+	}
+	signKeys := make([][]byte, 0, len(keys))
+	cipherKeys := make([][]byte, 0, len(keys))
+	for _, key := range keys {
+		if len(key) == 0 {
+			panic("cookie key can not be empty")
+		}
+		// derive different keys, so the signature never reveals anything about the encryption key
+		signKeys = append(signKeys, deriveCookieKey(key, signedCookiePurpose))
+		cipherKeys = append(cipherKeys, deriveCookieKey(key, encryptedCookiePurpose))
+	}
+	e.cookieCodec = &cookieCodec{signer: internal.NewSigner(signKeys...), cipher: internal.NewCipher(cipherKeys...)}
+}
+
+// deriveCookieKey returns 32 bytes key for purpose
+func deriveCookieKey(key []byte, purpose string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(purpose))
+	return h.Sum(nil)
+}
+
+// cookies returns cookieCodec of Engine, panic if keys not set
+func (e *Engine) cookies() *cookieCodec {
+	if e.cookieCodec == nil {
+		panic("cookie keys not set, call Engine.SetCookieKeys first")
+	}
+	return e.cookieCodec
+}
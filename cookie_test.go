@@ -0,0 +1,83 @@
+package regia
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newCookieEngine(keys ...[]byte) *Engine {
+	engine := New()
+	engine.SetCookieKeys(keys...)
+	engine.GET("/set", func(c *Context) {
+		c.SetSignedCookie(&http.Cookie{Name: "signed", Value: "regia", Path: "/"})
+		c.SetEncryptedCookie(&http.Cookie{Name: "encrypted", Value: "secret", Path: "/"})
+	})
+	engine.GET("/get", func(c *Context) {
+		signed, err := c.SignedCookie("signed")
+		if err != nil {
+			_ = c.String(err.Error())
+			return
+		}
+		encrypted, err := c.EncryptedCookie("encrypted")
+		if err != nil {
+			_ = c.String(err.Error())
+			return
+		}
+		_ = c.String(signed + " " + encrypted)
+	})
+	_ = engine.init()
+	return engine
+}
+
+func TestSignedAndEncryptedCookie(t *testing.T) {
+	oldEngine := newCookieEngine([]byte("old key"))
+	recorder := httptest.NewRecorder()
+	oldEngine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/set", nil))
+	cookies := recorder.Result().Cookies()
+	if len(cookies) != 2 || strings.Contains(cookies[1].Value, "secret") {
+		t.Fatalf("unexpected cookies %v", cookies)
+	}
+	get := func(engine *Engine, cookies ...*http.Cookie) string {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodGet, "/get", nil)
+		for _, cookie := range cookies {
+			request.AddCookie(cookie)
+		}
+		engine.ServeHTTP(recorder, request)
+		return recorder.Body.String()
+	}
+
+	if body := get(oldEngine, cookies...); body != "regia secret" {
+		t.Errorf("expected cookie values, got %q", body)
+	}
+	// cookies of old key are accepted after rotation
+	if body := get(newCookieEngine([]byte("new key"), []byte("old key")), cookies...); body != "regia secret" {
+		t.Errorf("expected rotated key accepted, got %q", body)
+	}
+	if body := get(newCookieEngine([]byte("new key")), cookies...); body != ErrCookieInvalid.Error() {
+		t.Errorf("expected invalid cookie for unknown key, got %q", body)
+	}
+
+	tampered := *cookies[0]
+	tampered.Value = "YWRtaW4" + tampered.Value[strings.Index(tampered.Value, "."):]
+	if body := get(oldEngine, &tampered, cookies[1]); body != ErrCookieInvalid.Error() {
+		t.Errorf("expected tampered cookie rejected, got %q", body)
+	}
+	if body := get(oldEngine); body != http.ErrNoCookie.Error() {
+		t.Errorf("expected no cookie, got %q", body)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic without cookie keys")
+		}
+	}()
+	engine := New()
+	engine.GET("/", func(c *Context) { _, _ = c.SignedCookie("signed") })
+	_ = engine.init()
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request.AddCookie(&http.Cookie{Name: "signed", Value: "x"})
+	engine.ServeHTTP(httptest.NewRecorder(), request)
+}
@@ -0,0 +1,164 @@
+// Copyright 2022 eatmoreapple.  All rights reserved.
+// Use of this source code is governed by a GPL style
+// license that can be found in the LICENSE file.
+
+package regia
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSConfig is the config of CORS
+type CORSConfig struct {
+	// AllowOrigins are the origins allowed, such as
+	//
+	//	https://example.com     exact origin
+	//	https://*.example.com   any subdomain of example.com
+	//	*                       any origin
+	AllowOrigins []string
+	// AllowOriginFunc reports whether the origin is allowed
+	// It is called if the origin does not match AllowOrigins
+	AllowOriginFunc func(origin string) bool
+	// AllowMethods are the methods answered to preflight request
+	// If empty, the methods registered for the path will be used
+	AllowMethods []string
+	// AllowHeaders are the headers answered to preflight request
+	// If empty, the headers requested will be allowed
+	AllowHeaders []string
+	// ExposeHeaders are the headers which can be read by client
+	ExposeHeaders []string
+	// AllowCredentials allows cookies and authorization headers
+	// The origin will be echoed instead of * if true
+	AllowCredentials bool
+	// MaxAge is how long the result of preflight request can be cached
+	MaxAge time.Duration
+	// AllowPrivateNetwork allows requests from public network to private network
+	AllowPrivateNetwork bool
+}
+
+// originMatcher matches origin with exact and wildcard patterns
+type originMatcher struct {
+	any       bool
+	exact     map[string]struct{}
+	wildcards [][2]string
+	fn        func(origin string) bool
+}
+
+func newOriginMatcher(config CORSConfig) *originMatcher {
+	matcher := &originMatcher{exact: make(map[string]struct{}), fn: config.AllowOriginFunc}
+	for _, origin := range config.AllowOrigins {
+		origin = strings.ToLower(strings.TrimSpace(origin))
+		switch {
+		case origin == "*":
+			matcher.any = true
+		case strings.Contains(origin, "*"):
+			index := strings.Index(origin, "*")
+			matcher.wildcards = append(matcher.wildcards, [2]string{origin[:index], origin[index+1:]})
+		default:
+			matcher.exact[origin] = struct{}{}
+		}
+	}
+	return matcher
+}
+
+func (m *originMatcher) match(origin string) bool {
+	if m.any {
+		return true
+	}
+	lower := strings.ToLower(origin)
+	if _, ok := m.exact[lower]; ok {
+		return true
+	}
+	for _, wildcard := range m.wildcards {
+		prefix, suffix := wildcard[0], wildcard[1]
+		if len(lower) > len(prefix)+len(suffix) && strings.HasPrefix(lower, prefix) && strings.HasSuffix(lower, suffix) {
+			// the wildcard only matches subdomain
+			if sub := lower[len(prefix) : len(lower)-len(suffix)]; !strings.ContainsAny(sub, "/:") {
+				return true
+			}
+		}
+	}
+	return m.fn != nil && m.fn(origin)
+}
+
+// CORS returns a middleware which handles Cross-Origin Resource Sharing
+// Add it by Engine.AddInterceptors so that preflight requests of unmatched routes are answered
+//
+//	engine.AddInterceptors(regia.CORS(regia.CORSConfig{
+//		AllowOrigins:     []string{"https://*.example.com"},
+//		AllowCredentials: true,
+//		MaxAge:           time.Hour,
+//	}))
+func CORS(config CORSConfig) HandleFunc {
+	matcher := newOriginMatcher(config)
+	allowMethods := strings.Join(config.AllowMethods, ", ")
+	allowHeaders := strings.Join(config.AllowHeaders, ", ")
+	exposeHeaders := strings.Join(config.ExposeHeaders, ", ")
+	maxAge := strconv.Itoa(int(config.MaxAge / time.Second))
+	// * can not be used with credentials
+	wildcardOrigin := matcher.any && !config.AllowCredentials
+
+	return func(c *Context) {
+		header := c.ResponseWriter.Header()
+		if !wildcardOrigin {
+			// response depends on origin
+			addVary(header, "Origin")
+		}
+		origin := c.Request.Header.Get("Origin")
+		if len(origin) == 0 {
+			return
+		}
+		preflight := c.Request.Method == http.MethodOptions && len(c.Request.Header.Get("Access-Control-Request-Method")) > 0
+		if !matcher.match(origin) {
+			if preflight {
+				c.AbortWithStatus(http.StatusForbidden)
+			}
+			return
+		}
+
+		if wildcardOrigin {
+			header.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			header.Set("Access-Control-Allow-Origin", origin)
+		}
+		if config.AllowCredentials {
+			header.Set("Access-Control-Allow-Credentials", "true")
+		}
+		if !preflight {
+			if len(exposeHeaders) > 0 {
+				header.Set("Access-Control-Expose-Headers", exposeHeaders)
+			}
+			return
+		}
+
+		// answer preflight request
+		addVary(header, "Access-Control-Request-Method", "Access-Control-Request-Headers")
+		methods := allowMethods
+		if len(methods) == 0 {
+			registered := c.engine.Router.AllowedMethods(c.Request.URL.Path)
+			if len(registered) == 0 {
+				// no such route
+				header.Del("Access-Control-Allow-Origin")
+				header.Del("Access-Control-Allow-Credentials")
+				return
+			}
+			methods = strings.Join(registered, ", ")
+		}
+		header.Set("Access-Control-Allow-Methods", methods)
+		if len(allowHeaders) > 0 {
+			header.Set("Access-Control-Allow-Headers", allowHeaders)
+		} else if requested := c.Request.Header.Get("Access-Control-Request-Headers"); len(requested) > 0 {
+			header.Set("Access-Control-Allow-Headers", requested)
+		}
+		if config.MaxAge > 0 {
+			header.Set("Access-Control-Max-Age", maxAge)
+		}
+		if config.AllowPrivateNetwork && c.Request.Header.Get("Access-Control-Request-Private-Network") == "true" {
+			header.Set("Access-Control-Allow-Private-Network", "true")
+		}
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+}
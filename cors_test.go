@@ -0,0 +1,67 @@
+package regia
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCORS(t *testing.T) {
+	engine := New()
+	engine.AddInterceptors(CORS(CORSConfig{
+		AllowOrigins:        []string{"https://example.com", "https://*.example.org"},
+		AllowOriginFunc:     func(origin string) bool { return origin == "http://localhost:3000" },
+		ExposeHeaders:       []string{"X-Total"},
+		AllowCredentials:    true,
+		MaxAge:              time.Hour,
+		AllowPrivateNetwork: true,
+	}))
+	engine.GET("/users/:id", func(c *Context) { _ = c.String("ok") })
+	engine.DELETE("/users/:id", func(c *Context) {})
+	_ = engine.init()
+
+	cases := []struct {
+		method  string
+		path    string
+		origin  string
+		request string
+		code    int
+		allowed string
+		methods string
+	}{
+		{http.MethodGet, "/users/1", "https://example.com", "", http.StatusOK, "https://example.com", ""},
+		{http.MethodGet, "/users/1", "https://api.example.org", "", http.StatusOK, "https://api.example.org", ""},
+		{http.MethodGet, "/users/1", "http://localhost:3000", "", http.StatusOK, "http://localhost:3000", ""},
+		{http.MethodGet, "/users/1", "https://evil.com", "", http.StatusOK, "", ""},
+		{http.MethodGet, "/users/1", "https://example.org", "", http.StatusOK, "", ""},
+		{http.MethodOptions, "/users/1", "https://example.com", http.MethodDelete, http.StatusNoContent, "https://example.com", "DELETE, GET"},
+		{http.MethodOptions, "/users/1", "https://evil.com", http.MethodDelete, http.StatusForbidden, "", ""},
+		{http.MethodOptions, "/missing", "https://example.com", http.MethodGet, http.StatusNotFound, "", ""},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		req.Header.Set("Origin", tc.origin)
+		if len(tc.request) > 0 {
+			req.Header.Set("Access-Control-Request-Method", tc.request)
+			req.Header.Set("Access-Control-Request-Headers", "X-Token")
+			req.Header.Set("Access-Control-Request-Private-Network", "true")
+		}
+		recorder := httptest.NewRecorder()
+		engine.ServeHTTP(recorder, req)
+		header := recorder.Header()
+		if recorder.Code != tc.code || header.Get("Access-Control-Allow-Origin") != tc.allowed ||
+			header.Get("Access-Control-Allow-Methods") != tc.methods {
+			t.Errorf("%s %s from %s: got %d %v", tc.method, tc.path, tc.origin, recorder.Code, header)
+			continue
+		}
+		if tc.code == http.StatusNoContent && (header.Get("Access-Control-Allow-Headers") != "X-Token" ||
+			header.Get("Access-Control-Max-Age") != "3600" || header.Get("Access-Control-Allow-Private-Network") != "true") {
+			t.Errorf("unexpected preflight headers %v", header)
+		}
+		if tc.code == http.StatusOK && len(tc.allowed) > 0 &&
+			(header.Get("Access-Control-Expose-Headers") != "X-Total" || header.Get("Access-Control-Allow-Credentials") != "true") {
+			t.Errorf("unexpected headers %v", header)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+// Copyright 2022 eatmoreapple.  All rights reserved.
+// Use of this source code is governed by a GPL style
+// license that can be found in the LICENSE file.
+
+package regia
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"html/template"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	csrfTokenLength   = 32
+	csrfTokenKey      = "regia.csrf_token"
+	defaultCSRFCookie = "_csrf"
+	defaultCSRFHeader = "X-CSRF-Token"
+	defaultCSRFField  = "csrf_token"
+	defaultCSRFMaxAge = 12 * time.Hour
+	csrfTokenFuncName = "csrfToken"
+	csrfFieldFuncName = "csrfField"
+)
+
+var (
+	// ErrCSRFTokenMissing returned when the request of unsafe method has no csrf token
+	ErrCSRFTokenMissing = NewHttpError(http.StatusForbidden, "csrf token missing")
+	// ErrCSRFTokenInvalid returned when the csrf token does not match the cookie
+	ErrCSRFTokenInvalid = NewHttpError(http.StatusForbidden, "csrf token invalid")
+)
+
+func init() {
+	registerRequestTemplateFunc(csrfTokenFuncName, csrfFieldFuncName)
+}
+
+// CSRFConfig is the config of CSRF
+type CSRFConfig struct {
+	// CookieName is the name of token cookie, default _csrf
+	CookieName   string
+	CookiePath   string
+	CookieDomain string
+	// Secure sends the cookie over https only
+	Secure bool
+	// HTTPOnly hides the cookie from javascript
+	// Keep it false if the token is read from cookie by javascript
+	HTTPOnly bool
+	// SameSite default http.SameSiteLaxMode
+	SameSite http.SameSite
+	// MaxAge of the cookie, default 12 hours
+	MaxAge time.Duration
+	// HeaderName is where the token is submitted, default X-CSRF-Token
+	HeaderName string
+	// FieldName is the form field of token if not found in header, default csrf_token
+	FieldName string
+	// ExemptPaths are not checked, they match both request path and FullPath
+	ExemptPaths []string
+	// Skip reports whether the request should not be checked
+	Skip func(c *Context) bool
+}
+
+// CSRF returns a middleware against Cross-Site Request Forgery with double submit cookie
+// The token is issued by cookie, and must be submitted by header or form field
+// for requests of unsafe methods, such as POST, PUT, PATCH and DELETE
+// The token can be rendered in templates of TemplateLoader
+//
+//	<form method="post">
+//		{{ csrfField }}
+//	</form>
+//	<meta name="csrf-token" content="{{ csrfToken }}">
+func CSRF(config CSRFConfig) HandleFunc {
+	if len(config.CookieName) == 0 {
+		config.CookieName = defaultCSRFCookie
+	}
+	if len(config.CookiePath) == 0 {
+		config.CookiePath = "/"
+	}
+	if config.SameSite == 0 {
+		config.SameSite = http.SameSiteLaxMode
+	}
+	if config.MaxAge == 0 {
+		config.MaxAge = defaultCSRFMaxAge
+	}
+	if len(config.HeaderName) == 0 {
+		config.HeaderName = defaultCSRFHeader
+	}
+	if len(config.FieldName) == 0 {
+		config.FieldName = defaultCSRFField
+	}
+	exemptPaths := make(map[string]struct{}, len(config.ExemptPaths))
+	for _, path := range config.ExemptPaths {
+		exemptPaths[path] = struct{}{}
+	}
+
+	return func(c *Context) {
+		token := csrfCookieToken(c, config.CookieName)
+		if token == nil {
+			token = make([]byte, csrfTokenLength)
+			if _, err := rand.Read(token); err != nil {
+				c.AbortWithError(err)
+				return
+			}
+			c.SetCookie(&http.Cookie{
+				Name:     config.CookieName,
+				Value:    base64.RawURLEncoding.EncodeToString(token),
+				Path:     config.CookiePath,
+				Domain:   config.CookieDomain,
+				MaxAge:   int(config.MaxAge / time.Second),
+				Secure:   config.Secure,
+				HttpOnly: config.HTTPOnly,
+				SameSite: config.SameSite,
+			})
+		}
+		addVary(c.ResponseWriter.Header(), "Cookie")
+
+		// masked token differs in every response against BREACH attack
+		masked := maskCSRFToken(token)
+		c.SetValue(csrfTokenKey, masked)
+		c.SetTemplateFunc(csrfTokenFuncName, func() string { return masked })
+		c.SetTemplateFunc(csrfFieldFuncName, func() template.HTML {
+			return template.HTML(`<input type="hidden" name="` + template.HTMLEscapeString(config.FieldName) +
+				`" value="` + masked + `">`)
+		})
+
+		if isSafeMethod(c.Request.Method) || csrfExempt(c, exemptPaths, config.Skip) {
+			return
+		}
+		submitted := c.Request.Header.Get(config.HeaderName)
+		if len(submitted) == 0 {
+			submitted = csrfFormToken(c, config.FieldName)
+		}
+		if len(submitted) == 0 {
+			c.AbortWithError(ErrCSRFTokenMissing)
+			return
+		}
+		if !validCSRFToken(token, submitted) {
+			c.AbortWithError(ErrCSRFTokenInvalid)
+			return
+		}
+	}
+}
+
+// CSRFToken returns the masked csrf token of current request set by CSRF
+// It can be sent to client by header or rendered in response
+func CSRFToken(c *Context) string {
+	token, _ := c.GetValue(csrfTokenKey)
+	masked, _ := token.(string)
+	return masked
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+func csrfExempt(c *Context, exemptPaths map[string]struct{}, skip func(c *Context) bool) bool {
+	if _, ok := exemptPaths[c.Request.URL.Path]; ok {
+		return true
+	}
+	if _, ok := exemptPaths[c.FullPath()]; ok {
+		return true
+	}
+	return skip != nil && skip(c)
+}
+
+// csrfCookieToken returns the token of cookie, nil if missing or invalid
+func csrfCookieToken(c *Context, name string) []byte {
+	cookie, err := c.Request.Cookie(name)
+	if err != nil {
+		return nil
+	}
+	token, err := base64.RawURLEncoding.DecodeString(cookie.Value)
+	if err != nil || len(token) != csrfTokenLength {
+		return nil
+	}
+	return token
+}
+
+// csrfFormToken returns the token of form field
+func csrfFormToken(c *Context, field string) string {
+	if err := c.prepareBody(); err != nil {
+		return ""
+	}
+	if strings.Contains(strings.ToLower(c.ContentType()), mimeMultipartPostForm) {
+		if err := c.Request.ParseMultipartForm(c.engine.MultipartMemory); err != nil {
+			return ""
+		}
+		return c.Request.PostFormValue(field)
+	}
+	return c.FormValue(field)
+}
+
+// maskCSRFToken returns base64 of one time pad and token xor pad
+func maskCSRFToken(token []byte) string {
+	masked := make([]byte, 2*len(token))
+	pad := masked[:len(token)]
+	if _, err := rand.Read(pad); err != nil {
+		// fallback to the raw token
+		return base64.RawURLEncoding.EncodeToString(token)
+	}
+	for i := range token {
+		masked[len(token)+i] = token[i] ^ pad[i]
+	}
+	return base64.RawURLEncoding.EncodeToString(masked)
+}
+
+// validCSRFToken reports whether the submitted token matches the token of cookie
+// Both masked and raw tokens are accepted
+func validCSRFToken(token []byte, submitted string) bool {
+	data, err := base64.RawURLEncoding.DecodeString(submitted)
+	if err != nil {
+		return false
+	}
+	switch len(data) {
+	case csrfTokenLength:
+	case 2 * csrfTokenLength:
+		pad, masked := data[:csrfTokenLength], data[csrfTokenLength:]
+		for i := range masked {
+			masked[i] ^= pad[i]
+		}
+		data = masked
+	default:
+		return false
+	}
+	return subtle.ConstantTimeCompare(token, data) == 1
+}
@@ -0,0 +1,108 @@
+package regia
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestCSRF(t *testing.T) {
+	dir := t.TempDir()
+	page := `<form>{{ csrfField }}</form>`
+	if err := os.WriteFile(filepath.Join(dir, "form.html"), []byte(page), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	engine := New()
+	loader := &TemplateLoader{}
+	if err := loader.ParseGlob(filepath.Join(dir, "*.html")); err != nil {
+		t.Fatal(err)
+	}
+	engine.SetHTMLLoader(loader)
+	engine.AddInterceptors(CSRF(CSRFConfig{ExemptPaths: []string{"/hooks/:name"}}))
+	engine.GET("/form", func(c *Context) { _ = c.HTML("form.html", nil) })
+	engine.POST("/form", func(c *Context) { _ = c.String("ok") })
+	engine.POST("/hooks/:name", func(c *Context) { _ = c.String("hook") })
+	_ = engine.init()
+
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/form", nil))
+	cookies := recorder.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != defaultCSRFCookie {
+		t.Fatalf("expected csrf cookie, got %v", cookies)
+	}
+	matches := regexp.MustCompile(`name="csrf_token" value="([^"]+)"`).FindStringSubmatch(recorder.Body.String())
+	if len(matches) != 2 {
+		t.Fatalf("expected csrf field, got %q", recorder.Body.String())
+	}
+	token := matches[1]
+
+	post := func(path, header, field string, withCookie bool) int {
+		form := url.Values{}
+		if len(field) > 0 {
+			form.Set(defaultCSRFField, field)
+		}
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if len(header) > 0 {
+			req.Header.Set(defaultCSRFHeader, header)
+		}
+		if withCookie {
+			req.AddCookie(cookies[0])
+		}
+		recorder := httptest.NewRecorder()
+		engine.ServeHTTP(recorder, req)
+		return recorder.Code
+	}
+	// change the first byte, so the token is always different
+	tampered := []byte(token)
+	if tampered[0] == 'a' {
+		tampered[0] = 'b'
+	} else {
+		tampered[0] = 'a'
+	}
+	cases := []struct {
+		name   string
+		code   int
+		actual int
+	}{
+		{"form field", http.StatusOK, post("/form", "", token, true)},
+		{"header", http.StatusOK, post("/form", token, "", true)},
+		{"raw cookie token", http.StatusOK, post("/form", cookies[0].Value, "", true)},
+		{"missing token", http.StatusForbidden, post("/form", "", "", true)},
+		{"missing cookie", http.StatusForbidden, post("/form", token, "", false)},
+		{"invalid token", http.StatusForbidden, post("/form", string(tampered), "", true)},
+		{"exempt path", http.StatusOK, post("/hooks/github", "", "", false)},
+	}
+	for _, tc := range cases {
+		if tc.actual != tc.code {
+			t.Errorf("%s: got %d, want %d", tc.name, tc.actual, tc.code)
+		}
+	}
+}
+
+func TestCSRFTokenNotLeaked(t *testing.T) {
+	engine := New()
+	protected := NewBluePrint()
+	protected.Use(CSRF(CSRFConfig{}))
+	protected.GET("/", func(c *Context) { _ = c.String(CSRFToken(c)) })
+	engine.Include("/protected", protected)
+	engine.GET("/plain", func(c *Context) { _ = c.String(CSRFToken(c)) })
+	_ = engine.init()
+
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/protected/", nil))
+	if len(recorder.Body.String()) == 0 {
+		t.Fatal("expected csrf token")
+	}
+	// the Context is reused from the pool
+	recorder = httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/plain", nil))
+	if len(recorder.Body.String()) != 0 {
+		t.Errorf("csrf token of previous request leaked: %q", recorder.Body.String())
+	}
+}
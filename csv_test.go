@@ -0,0 +1,90 @@
+package regia
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eatmoreapple/regia/renders"
+)
+
+type csvBase struct {
+	ID int `csv:"id"`
+}
+
+type csvRow struct {
+	csvBase
+	Name   string    `csv:"name"`
+	Score  *float64  `csv:"score"`
+	Joined time.Time `csv:"joined"`
+	Secret string    `csv:"-"`
+}
+
+func TestCSV(t *testing.T) {
+	score := 9.5
+	joined := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+	rows := []csvRow{
+		{csvBase: csvBase{ID: 1}, Name: "regia", Score: &score, Joined: joined, Secret: "secret"},
+		{csvBase: csvBase{ID: 2}, Name: "a, \"quoted\" name"},
+	}
+
+	engine := New()
+	engine.GET("/", func(c *Context) { _ = c.CSV("rows.csv", rows) })
+	engine.GET("/chan", func(c *Context) {
+		source := make(chan *csvRow, len(rows))
+		for i := range rows {
+			source <- &rows[i]
+		}
+		close(source)
+		_ = c.Render(renders.CSV{Comma: ';', BOM: true}, source)
+	})
+	engine.POST("/", func(c *Context) {
+		var bound []csvRow
+		if err := c.BindCSV(&bound); err != nil {
+			c.SetStatus(http.StatusBadRequest)
+			c.AbortWithString(err.Error())
+			return
+		}
+		// the column of Secret is skipped
+		expected := append([]csvRow(nil), rows...)
+		expected[0].Secret = ""
+		if !reflect.DeepEqual(bound, expected) {
+			t.Errorf("unexpected rows %+v", bound)
+		}
+	})
+	_ = engine.init()
+
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	want := "id,name,score,joined\n1,regia,9.5,2022-01-02T03:04:05Z\n2,\"a, \"\"quoted\"\" name\",,\n"
+	if recorder.Body.String() != want {
+		t.Errorf("expected %q, got %q", want, recorder.Body.String())
+	}
+	if recorder.Header().Get("Content-Disposition") != `attachment; filename="rows.csv"` ||
+		recorder.Header().Get("Content-Type") != "text/csv; charset=utf-8" {
+		t.Errorf("unexpected headers %v", recorder.Header())
+	}
+
+	recorder = httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/chan", nil))
+	if want := "\xEF\xBB\xBFid;name;score;joined\n1;regia;9.5;2022-01-02T03:04:05Z\n2;\"a, \"\"quoted\"\" name\";;\n"; recorder.Body.String() != want {
+		t.Errorf("expected %q, got %q", want, recorder.Body.String())
+	}
+
+	// columns are matched by header, the bom and unknown columns are ignored
+	for _, body := range []string{
+		want,
+		"\xEF\xBB\xBFjoined,unknown,name,id,score\n2022-01-02T03:04:05Z,x,regia,1,9.5\n,,\"a, \"\"quoted\"\" name\",2,\n",
+	} {
+		recorder = httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		request.Header.Set("Content-Type", "text/csv")
+		engine.ServeHTTP(recorder, request)
+		if recorder.Code != http.StatusOK {
+			t.Errorf("expected 200, got %d %s", recorder.Code, recorder.Body.String())
+		}
+	}
+}
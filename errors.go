@@ -0,0 +1,77 @@
+// Copyright 2022 eatmoreapple.  All rights reserved.
+// Use of this source code is governed by a GPL style
+// license that can be found in the LICENSE file.
+
+package regia
+
+import (
+	"errors"
+	"net/http"
+)
+
+// HttpError is an error with http status code
+// Engine.ErrorHandle will reply with its Code and Message
+type HttpError struct {
+	Code    int
+	Message string
+	// Err is the underlying error, can be nil
+	Err error
+}
+
+func (h *HttpError) Error() string {
+	if h.Err != nil {
+		return h.Message + ": " + h.Err.Error()
+	}
+	return h.Message
+}
+
+func (h *HttpError) Unwrap() error {
+	return h.Err
+}
+
+// StatusCode returns http status code of HttpError
+func (h *HttpError) StatusCode() int {
+	return h.Code
+}
+
+// NewHttpError constructor for HttpError
+// If message is empty, status text of code will be used
+func NewHttpError(code int, message string) *HttpError {
+	if len(message) == 0 {
+		message = http.StatusText(code)
+	}
+	return &HttpError{Code: code, Message: message}
+}
+
+// statusCoder is implemented by errors which carry http status code
+type statusCoder interface {
+	StatusCode() int
+}
+
+// detailer is implemented by errors which carry structured details
+// such as the failed fields of binding or validation
+type detailer interface {
+	Detail() interface{}
+}
+
+// HandleError replies to the request with the status code of err and a json body
+// If err does not carry status code, 500 will be used
+// The message of 5xx errors will be hidden
+func HandleError(context *Context, err error) {
+	code := http.StatusInternalServerError
+	var coder statusCoder
+	if errors.As(err, &coder) {
+		code = coder.StatusCode()
+	}
+	message := err.Error()
+	if code >= http.StatusInternalServerError {
+		message = http.StatusText(code)
+	}
+	body := Map{"code": code, "message": message}
+	var d detailer
+	if errors.As(err, &d) {
+		body["detail"] = d.Detail()
+	}
+	context.SetStatus(code)
+	_ = context.JSON(body)
+}
@@ -0,0 +1,68 @@
+// Copyright 2022 eatmoreapple.  All rights reserved.
+// Use of this source code is governed by a GPL style
+// license that can be found in the LICENSE file.
+
+package internal
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+)
+
+// Cipher encrypts values with AES-GCM
+// The first key encrypts and all keys decrypt, so keys can be rotated
+type Cipher struct {
+	aeads []cipher.AEAD
+}
+
+// NewCipher constructor for Cipher
+// Each key must be 16, 24 or 32 bytes to select AES-128, AES-192 or AES-256
+func NewCipher(keys ...[]byte) *Cipher {
+	if len(keys) == 0 {
+		panic("cipher needs at least one key")
+	}
+	aeads := make([]cipher.AEAD, 0, len(keys))
+	for _, key := range keys {
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			panic(err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			panic(err)
+		}
+		aeads = append(aeads, aead)
+	}
+	return &Cipher{aeads: aeads}
+}
+
+// Encrypt returns base64 of the random nonce followed by the sealed value
+// name is authenticated together, so the value can not be moved to another name
+func (c *Cipher) Encrypt(name string, value []byte) string {
+	aead := c.aeads[0]
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(value)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(aead.Seal(nonce, nonce, value, []byte(name)))
+}
+
+// Decrypt returns the value if encrypted by any key with name
+func (c *Cipher) Decrypt(name, encrypted string) ([]byte, bool) {
+	data, err := base64.RawURLEncoding.DecodeString(encrypted)
+	if err != nil {
+		return nil, false
+	}
+	for _, aead := range c.aeads {
+		if len(data) < aead.NonceSize() {
+			continue
+		}
+		nonce, sealed := data[:aead.NonceSize()], data[aead.NonceSize():]
+		if value, err := aead.Open(nil, nonce, sealed, []byte(name)); err == nil {
+			return value, true
+		}
+	}
+	return nil, false
+}
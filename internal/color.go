@@ -4,7 +4,11 @@
 
 package internal
 
-import "fmt"
+import (
+	"fmt"
+	"io"
+	"os"
+)
 
 const colorFormat = "\u001B[%dm%s\u001B[0m"
 
@@ -16,6 +20,20 @@ const (
 	Magenta             // magenta
 )
 
+// ColorEnabled reports whether writer is a terminal which shows colors
+// Colors are disabled by the NO_COLOR environment variable, see https://no-color.org
+func ColorEnabled(writer io.Writer) bool {
+	if len(os.Getenv("NO_COLOR")) > 0 {
+		return false
+	}
+	file, ok := writer.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
 // FormatColor returns a color representation of given color value
 func FormatColor(color int, v interface{}) string {
 	return fmt.Sprintf(colorFormat, color, fmt.Sprintf("%+v", v))
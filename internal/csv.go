@@ -0,0 +1,47 @@
+// Copyright 2022 eatmoreapple.  All rights reserved.
+// Use of this source code is governed by a GPL style
+// license that can be found in the LICENSE file.
+
+package internal
+
+import "reflect"
+
+const csvTag = "csv"
+
+// CSVColumn describes a struct field mapped to a csv column
+type CSVColumn struct {
+	// Name is the header of column
+	Name string
+	// Index is the index sequence for reflect.Value.FieldByIndex
+	Index []int
+}
+
+// CSVColumns returns the columns of given struct type in field order
+// Column name comes from `csv` tag, or field name if tag not set
+// Fields tagged with "-" and unexported fields will be skipped
+// Fields of embedded struct will be promoted
+func CSVColumns(t reflect.Type) []CSVColumn {
+	var columns []CSVColumn
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag, exist := field.Tag.Lookup(csvTag)
+		if tag == "-" {
+			continue
+		}
+		if field.Anonymous && !exist && field.Type.Kind() == reflect.Struct {
+			for _, column := range CSVColumns(field.Type) {
+				column.Index = append([]int{i}, column.Index...)
+				columns = append(columns, column)
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if !exist || tag == "" {
+			tag = field.Name
+		}
+		columns = append(columns, CSVColumn{Name: tag, Index: []int{i}})
+	}
+	return columns
+}
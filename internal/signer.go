@@ -0,0 +1,65 @@
+// Copyright 2022 eatmoreapple.  All rights reserved.
+// Use of this source code is governed by a GPL style
+// license that can be found in the LICENSE file.
+
+package internal
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+)
+
+// Signer signs values with HMAC-SHA256
+// The first key signs and all keys verify, so keys can be rotated
+type Signer struct {
+	keys [][]byte
+}
+
+// NewSigner constructor for Signer
+func NewSigner(keys ...[]byte) *Signer {
+	if len(keys) == 0 {
+		panic("signer needs at least one key")
+	}
+	for _, key := range keys {
+		if len(key) == 0 {
+			panic("signer key can not be empty")
+		}
+	}
+	return &Signer{keys: keys}
+}
+
+// Sign returns base64 of value and its signature joined by dot
+// name is signed together, so the value can not be moved to another name
+func (s *Signer) Sign(name string, value []byte) string {
+	payload := base64.RawURLEncoding.EncodeToString(value)
+	return payload + "." + base64.RawURLEncoding.EncodeToString(s.mac(s.keys[0], name, payload))
+}
+
+// Verify returns the value if signed is signed by any key with name
+func (s *Signer) Verify(name, signed string) ([]byte, bool) {
+	payload, encoded, ok := strings.Cut(signed, ".")
+	if !ok {
+		return nil, false
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, false
+	}
+	for _, key := range s.keys {
+		if hmac.Equal(signature, s.mac(key, name, payload)) {
+			value, err := base64.RawURLEncoding.DecodeString(payload)
+			return value, err == nil
+		}
+	}
+	return nil, false
+}
+
+func (s *Signer) mac(key []byte, name, payload string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(name))
+	h.Write([]byte{0})
+	h.Write([]byte(payload))
+	return h.Sum(nil)
+}
@@ -5,7 +5,6 @@
 package internal
 
 import (
-	"reflect"
 	"unsafe"
 )
 
@@ -13,5 +12,5 @@ import (
 // unsafe string to byte
 // without memory copy
 func StringToByte(s string) []byte {
-	return *(*[]byte)(unsafe.Pointer(&*(*reflect.StringHeader)(unsafe.Pointer(&s))))
+	return unsafe.Slice(unsafe.StringData(s), len(s))
 }
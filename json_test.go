@@ -0,0 +1,85 @@
+package regia
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/eatmoreapple/regia/renders"
+)
+
+func TestJSONRenders(t *testing.T) {
+	engine := New()
+	if engine.SecureJSONPrefix != "while(1);" {
+		t.Errorf("unexpected default prefix %q", engine.SecureJSONPrefix)
+	}
+	engine.SecureJSONPrefix = ")]}',\n"
+	data := Map{"name": "regia 雷吉亚 😀"}
+	engine.GET("/indented", func(c *Context) { _ = c.IndentedJSON(data) })
+	engine.GET("/ascii", func(c *Context) { _ = c.AsciiJSON(data) })
+	engine.GET("/secure", func(c *Context) { _ = c.SecureJSON(data) })
+	engine.GET("/jsonp", func(c *Context) {
+		if err := c.JSONP(c.Query().Get("callback"), data); err != nil {
+			c.SetStatus(http.StatusBadRequest)
+			c.AbortWithString(err.Error())
+		}
+	})
+	_ = engine.init()
+
+	tests := []struct {
+		path        string
+		code        int
+		contentType string
+		body        string
+	}{
+		{"/indented", http.StatusOK, "application/json; charset=utf-8", "{\n    \"name\": \"regia 雷吉亚 😀\"\n}\n"},
+		{"/ascii", http.StatusOK, "application/json", `{"name":"regia \u96f7\u5409\u4e9a \ud83d\ude00"}` + "\n"},
+		{"/secure", http.StatusOK, "application/json; charset=utf-8", ")]}',\n" + `{"name":"regia 雷吉亚 😀"}` + "\n"},
+		{"/jsonp?callback=app.handle_1", http.StatusOK, "application/javascript; charset=utf-8",
+			`/**/ typeof app.handle_1 === 'function' && app.handle_1({"name":"regia 雷吉亚 😀"});`},
+	}
+	for _, test := range tests {
+		recorder := httptest.NewRecorder()
+		engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, test.path, nil))
+		if recorder.Code != test.code || recorder.Header().Get("Content-Type") != test.contentType ||
+			recorder.Body.String() != test.body {
+			t.Errorf("%s: got %d %q %q", test.path, recorder.Code, recorder.Header().Get("Content-Type"), recorder.Body.String())
+		}
+	}
+
+	for _, callback := range []string{"", "alert(1)//", "a..b", "1a", "a;b", "<script>", "a.b-c"} {
+		recorder := httptest.NewRecorder()
+		engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/jsonp?callback="+callback, nil))
+		if recorder.Code != http.StatusBadRequest {
+			t.Errorf("callback %q should be rejected, got %d %q", callback, recorder.Code, recorder.Body.String())
+		}
+	}
+}
+
+// rawStringSerializer writes strings quoted but not escaped
+type rawStringSerializer struct{}
+
+func (rawStringSerializer) Encode(writer io.Writer, v interface{}) error {
+	_, err := io.WriteString(writer, `"`+v.(string)+`"`)
+	return err
+}
+
+func (rawStringSerializer) Decode(io.Reader, interface{}) error { return nil }
+
+func TestJsonpRender(t *testing.T) {
+	render := renders.JsonpRender{Serializer: rawStringSerializer{}, Callback: "$cb"}
+	recorder := httptest.NewRecorder()
+	// U+2028 and U+2029 end a javascript string, they must be escaped even if the serializer does not
+	if err := render.Render(recorder, "a\u2028b\u2029c"); err != nil {
+		t.Fatal(err)
+	}
+	if want := `/**/ typeof $cb === 'function' && $cb("a\u2028b\u2029c");`; recorder.Body.String() != want {
+		t.Errorf("expected %q, got %q", want, recorder.Body.String())
+	}
+	render.Callback = "x(1)"
+	if err := render.Render(httptest.NewRecorder(), ""); !errors.Is(err, renders.ErrInvalidJSONPCallback) {
+		t.Errorf("expected ErrInvalidJSONPCallback, got %v", err)
+	}
+}
@@ -0,0 +1,575 @@
+// Copyright 2022 eatmoreapple.  All rights reserved.
+// Use of this source code is governed by a GPL style
+// license that can be found in the LICENSE file.
+
+package regia
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const jwtClaimsKey = "regia.jwt_claims"
+
+// Algorithms supported by JWT
+const (
+	JWTHS256 = "HS256"
+	JWTRS256 = "RS256"
+	JWTES256 = "ES256"
+	JWTEdDSA = "EdDSA"
+)
+
+var (
+	// ErrTokenMissing returned when the request has no token
+	ErrTokenMissing = NewHttpError(http.StatusUnauthorized, "missing token")
+	// ErrTokenMalformed returned when the token can not be decoded
+	ErrTokenMalformed error = tokenError("token malformed")
+	// ErrTokenAlgorithm returned when the algorithm of token is not allowed
+	ErrTokenAlgorithm error = tokenError("token algorithm not allowed")
+	// ErrTokenKey returned when no key verifies the token
+	ErrTokenKey error = tokenError("token key not found")
+	// ErrTokenSignature returned when the signature of token is invalid
+	ErrTokenSignature error = tokenError("token signature invalid")
+	// ErrTokenExpired returned when the token is expired
+	ErrTokenExpired error = tokenError("token expired")
+	// ErrTokenNotValidYet returned when the token is used before its nbf
+	ErrTokenNotValidYet error = tokenError("token not valid yet")
+	// ErrTokenIssuer returned when the issuer of token is not expected
+	ErrTokenIssuer error = tokenError("token issuer invalid")
+	// ErrTokenAudience returned when the token is not issued for the audience
+	ErrTokenAudience error = tokenError("token audience invalid")
+)
+
+// tokenError is the error of invalid token, other errors of verifying are caused by server
+type tokenError string
+
+func (t tokenError) Error() string {
+	return string(t)
+}
+
+// JWTHeader is the header of JWT
+type JWTHeader struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid,omitempty"`
+	Type      string `json:"typ,omitempty"`
+}
+
+// JWTClaims is the claims of JWT
+// Numbers are decoded as json.Number
+type JWTClaims map[string]interface{}
+
+// String returns the claim as string
+func (j JWTClaims) String(name string) string {
+	value, _ := j[name].(string)
+	return value
+}
+
+// Strings returns the claim as strings, a single string is returned as one element
+func (j JWTClaims) Strings(name string) []string {
+	switch value := j[name].(type) {
+	case string:
+		return []string{value}
+	case []interface{}:
+		values := make([]string, 0, len(value))
+		for _, v := range value {
+			if s, ok := v.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// Int64 returns the claim as int64
+func (j JWTClaims) Int64(name string) (int64, bool) {
+	number, ok := j[name].(json.Number)
+	if !ok {
+		return 0, false
+	}
+	if value, err := number.Int64(); err == nil {
+		return value, true
+	}
+	value, err := number.Float64()
+	return int64(value), err == nil
+}
+
+// Float64 returns the claim as float64
+func (j JWTClaims) Float64(name string) (float64, bool) {
+	number, ok := j[name].(json.Number)
+	if !ok {
+		return 0, false
+	}
+	value, err := number.Float64()
+	return value, err == nil
+}
+
+// Bool returns the claim as bool
+func (j JWTClaims) Bool(name string) bool {
+	value, _ := j[name].(bool)
+	return value
+}
+
+// Time returns the claim of NumericDate as time
+func (j JWTClaims) Time(name string) (time.Time, bool) {
+	seconds, ok := j.Float64(name)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(0, int64(seconds*float64(time.Second))), true
+}
+
+// Subject returns the sub claim
+func (j JWTClaims) Subject() string {
+	return j.String("sub")
+}
+
+// Issuer returns the iss claim
+func (j JWTClaims) Issuer() string {
+	return j.String("iss")
+}
+
+// Audience returns the aud claim
+func (j JWTClaims) Audience() []string {
+	return j.Strings("aud")
+}
+
+// ID returns the jti claim
+func (j JWTClaims) ID() string {
+	return j.String("jti")
+}
+
+// ExpiresAt returns the exp claim, zero time if missing
+func (j JWTClaims) ExpiresAt() time.Time {
+	t, _ := j.Time("exp")
+	return t
+}
+
+// NotBefore returns the nbf claim, zero time if missing
+func (j JWTClaims) NotBefore() time.Time {
+	t, _ := j.Time("nbf")
+	return t
+}
+
+// IssuedAt returns the iat claim, zero time if missing
+func (j JWTClaims) IssuedAt() time.Time {
+	t, _ := j.Time("iat")
+	return t
+}
+
+// JWTConfig is the config of JWT
+type JWTConfig struct {
+	// Key verifies the token without kid or when JWKS is nil
+	// []byte for HS256, *rsa.PublicKey for RS256, *ecdsa.PublicKey for ES256 and ed25519.PublicKey for EdDSA
+	Key interface{}
+	// JWKS provides the keys by kid, see JWKSFile
+	// It is called on every request, so cache the keys in it
+	JWKS func() (*JWKSet, error)
+	// Algorithms are allowed, default all supported
+	// Set it if the key is not bound to an algorithm
+	Algorithms []string
+	// Issuer is checked if set
+	Issuer string
+	// Audience is checked if set, the token must contain it
+	Audience string
+	// Leeway tolerates the clock skew when checking exp and nbf
+	Leeway time.Duration
+	// TokenLookup is where the token is found, formatted as source:name
+	// source can be header, query or cookie, default header:Authorization with Bearer scheme
+	TokenLookup string
+	// Realm of WWW-Authenticate, default Restricted
+	Realm string
+	// Skip reports whether the request should not be authenticated
+	Skip func(c *Context) bool
+	// now is used to get current time, replaceable in test
+	now func() time.Time
+}
+
+// JWT returns a middleware which authenticates the request by JSON Web Token
+// The claims are stored in Context and can be got by GetJWTClaims
+// The rejected request is replied 401 with WWW-Authenticate of RFC 6750
+//
+//	engine.Use(regia.JWT(regia.JWTConfig{Key: secret, Issuer: "https://auth.example.com"}))
+func JWT(config JWTConfig) HandleFunc {
+	if config.Key == nil && config.JWKS == nil {
+		panic("jwt key or jwks must be set")
+	}
+	extract, challenge := authExtractor(config.TokenLookup, "", config.Realm)
+	return func(c *Context) {
+		if config.Skip != nil && config.Skip(c) {
+			return
+		}
+		token := extract(c)
+		if len(token) == 0 {
+			c.ResponseWriter.Header().Set("WWW-Authenticate", challenge)
+			c.AbortWithError(ErrTokenMissing)
+			return
+		}
+		claims, err := config.Verify(token)
+		var invalid tokenError
+		if err != nil && !errors.As(err, &invalid) {
+			// such as failed to load jwks
+			c.AbortWithError(err)
+			return
+		}
+		if err != nil {
+			c.ResponseWriter.Header().Set("WWW-Authenticate",
+				challenge+`, error="invalid_token", error_description="`+err.Error()+`"`)
+			c.AbortWithError(&HttpError{Code: http.StatusUnauthorized, Message: "invalid token", Err: err})
+			return
+		}
+		c.SetValue(jwtClaimsKey, claims)
+	}
+}
+
+// GetJWTClaims returns the claims verified by JWT
+func GetJWTClaims(c *Context) JWTClaims {
+	value, _ := c.GetValue(jwtClaimsKey)
+	claims, _ := value.(JWTClaims)
+	return claims
+}
+
+// Verify verifies the token with config and returns its claims
+// It can be used where the middleware does not fit, such as the first message of websocket
+func (j JWTConfig) Verify(token string) (JWTClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrTokenMalformed
+	}
+	var header JWTHeader
+	if err := decodeJWTPart(parts[0], &header); err != nil {
+		return nil, err
+	}
+	if !j.allowed(header.Algorithm) {
+		return nil, ErrTokenAlgorithm
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrTokenMalformed
+	}
+	key, err := j.key(header)
+	if err != nil {
+		return nil, err
+	}
+	if err = verifyJWTSignature(header.Algorithm, key, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+	var claims JWTClaims
+	if err = decodeJWTPart(parts[1], &claims); err != nil {
+		return nil, err
+	}
+	if err = j.validate(claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func (j JWTConfig) allowed(algorithm string) bool {
+	if len(j.Algorithms) == 0 {
+		switch algorithm {
+		case JWTHS256, JWTRS256, JWTES256, JWTEdDSA:
+			return true
+		}
+		return false
+	}
+	for _, allowed := range j.Algorithms {
+		if allowed == algorithm {
+			return true
+		}
+	}
+	return false
+}
+
+// key returns the key to verify the token
+func (j JWTConfig) key(header JWTHeader) (interface{}, error) {
+	if j.JWKS == nil {
+		return j.Key, nil
+	}
+	set, err := j.JWKS()
+	if err != nil {
+		return nil, err
+	}
+	if key, ok := set.Key(header.KeyID, header.Algorithm); ok {
+		return key, nil
+	}
+	if len(header.KeyID) == 0 && j.Key != nil {
+		return j.Key, nil
+	}
+	return nil, ErrTokenKey
+}
+
+func (j JWTConfig) validate(claims JWTClaims) error {
+	now := time.Now()
+	if j.now != nil {
+		now = j.now()
+	}
+	if _, exist := claims["exp"]; exist {
+		expire, ok := claims.Time("exp")
+		if !ok {
+			return ErrTokenMalformed
+		}
+		if !now.Before(expire.Add(j.Leeway)) {
+			return ErrTokenExpired
+		}
+	}
+	if _, exist := claims["nbf"]; exist {
+		notBefore, ok := claims.Time("nbf")
+		if !ok {
+			return ErrTokenMalformed
+		}
+		if now.Add(j.Leeway).Before(notBefore) {
+			return ErrTokenNotValidYet
+		}
+	}
+	if len(j.Issuer) > 0 && claims.Issuer() != j.Issuer {
+		return ErrTokenIssuer
+	}
+	if len(j.Audience) > 0 {
+		for _, audience := range claims.Audience() {
+			if audience == j.Audience {
+				return nil
+			}
+		}
+		return ErrTokenAudience
+	}
+	return nil
+}
+
+func decodeJWTPart(part string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return ErrTokenMalformed
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err = decoder.Decode(v); err != nil {
+		return ErrTokenMalformed
+	}
+	return nil
+}
+
+// verifyJWTSignature verifies the signature with key of the algorithm
+// The type of key must match the algorithm against algorithm confusion
+func verifyJWTSignature(algorithm string, key interface{}, signed string, signature []byte) error {
+	digest := sha256.Sum256([]byte(signed))
+	var valid bool
+	switch algorithm {
+	case JWTHS256:
+		secret, ok := key.([]byte)
+		if !ok || len(secret) == 0 {
+			return ErrTokenKey
+		}
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(signed))
+		valid = hmac.Equal(mac.Sum(nil), signature)
+	case JWTRS256:
+		publicKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return ErrTokenKey
+		}
+		valid = rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, digest[:], signature) == nil
+	case JWTES256:
+		publicKey, ok := key.(*ecdsa.PublicKey)
+		if !ok || publicKey.Curve != elliptic.P256() {
+			return ErrTokenKey
+		}
+		// the signature is r and s of 32 bytes each
+		if len(signature) != 64 {
+			return ErrTokenSignature
+		}
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		valid = ecdsa.Verify(publicKey, digest[:], r, s)
+	case JWTEdDSA:
+		publicKey, ok := key.(ed25519.PublicKey)
+		if !ok || len(publicKey) != ed25519.PublicKeySize {
+			return ErrTokenKey
+		}
+		valid = ed25519.Verify(publicKey, []byte(signed), signature)
+	default:
+		return ErrTokenAlgorithm
+	}
+	if !valid {
+		return ErrTokenSignature
+	}
+	return nil
+}
+
+// JWKSet is a set of JSON Web Keys
+type JWKSet struct {
+	keys []jwk
+}
+
+type jwk struct {
+	id        string
+	algorithm string
+	key       interface{}
+}
+
+// ParseJWKS parses JSON Web Key Set
+// Keys of RSA, EC P-256, OKP Ed25519 and oct are supported, others are ignored
+func ParseJWKS(data []byte) (*JWKSet, error) {
+	var raw struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Alg string `json:"alg"`
+			Use string `json:"use"`
+			Crv string `json:"crv"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+			K   string `json:"k"`
+		} `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	set := &JWKSet{keys: make([]jwk, 0, len(raw.Keys))}
+	for _, item := range raw.Keys {
+		if len(item.Use) > 0 && item.Use != "sig" {
+			continue
+		}
+		var key interface{}
+		var err error
+		switch {
+		case item.Kty == "RSA":
+			key, err = jwkRSAKey(item.N, item.E)
+		case item.Kty == "EC" && item.Crv == "P-256":
+			key, err = jwkECKey(item.X, item.Y)
+		case item.Kty == "OKP" && item.Crv == "Ed25519":
+			key, err = jwkDecode(item.X)
+			if err == nil {
+				key = ed25519.PublicKey(key.([]byte))
+			}
+		case item.Kty == "oct":
+			key, err = jwkDecode(item.K)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, errors.New("invalid jwk " + item.Kid + ": " + err.Error())
+		}
+		set.keys = append(set.keys, jwk{id: item.Kid, algorithm: item.Alg, key: key})
+	}
+	return set, nil
+}
+
+// Key returns the key of kid which could verify the algorithm
+// If kid is empty, the only key matching the algorithm is returned
+func (j *JWKSet) Key(kid, algorithm string) (interface{}, bool) {
+	var found interface{}
+	var count int
+	for _, key := range j.keys {
+		if len(key.algorithm) > 0 && key.algorithm != algorithm {
+			continue
+		}
+		if len(kid) > 0 {
+			if key.id == kid {
+				return key.key, true
+			}
+			continue
+		}
+		found = key.key
+		count++
+	}
+	return found, count == 1
+}
+
+// JWKSFile returns a JWKS provider of local file
+// The file is parsed again after it is modified, so keys can be rotated without restart
+func JWKSFile(name string) func() (*JWKSet, error) {
+	var (
+		lock    sync.Mutex
+		set     *JWKSet
+		modTime time.Time
+	)
+	return func() (*JWKSet, error) {
+		info, err := os.Stat(name)
+		if err != nil {
+			return nil, err
+		}
+		lock.Lock()
+		defer lock.Unlock()
+		if set != nil && info.ModTime().Equal(modTime) {
+			return set, nil
+		}
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		parsed, err := ParseJWKS(data)
+		if err != nil {
+			return nil, err
+		}
+		set, modTime = parsed, info.ModTime()
+		return set, nil
+	}
+}
+
+func jwkDecode(value string) ([]byte, error) {
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+	if err == nil && len(data) == 0 {
+		err = errors.New("empty key")
+	}
+	return data, err
+}
+
+func jwkRSAKey(n, e string) (*rsa.PublicKey, error) {
+	modulus, err := jwkDecode(n)
+	if err != nil {
+		return nil, err
+	}
+	exponent, err := jwkDecode(e)
+	if err != nil {
+		return nil, err
+	}
+	if len(exponent) > 4 {
+		return nil, errors.New("rsa exponent too large")
+	}
+	var value int
+	for _, b := range exponent {
+		value = value<<8 | int(b)
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(modulus), E: value}, nil
+}
+
+func jwkECKey(x, y string) (*ecdsa.PublicKey, error) {
+	xBytes, err := jwkDecode(x)
+	if err != nil {
+		return nil, err
+	}
+	yBytes, err := jwkDecode(y)
+	if err != nil {
+		return nil, err
+	}
+	if len(xBytes) > 32 || len(yBytes) > 32 {
+		return nil, errors.New("invalid ec point")
+	}
+	// crypto/ecdh checks whether the uncompressed point is on the curve
+	point := make([]byte, 65)
+	point[0] = 4
+	copy(point[33-len(xBytes):33], xBytes)
+	copy(point[65-len(yBytes):], yBytes)
+	if _, err = ecdh.P256().NewPublicKey(point); err != nil {
+		return nil, err
+	}
+	return &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(xBytes), Y: new(big.Int).SetBytes(yBytes)}, nil
+}
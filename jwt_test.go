@@ -0,0 +1,165 @@
+package regia
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func signJWT(t *testing.T, header JWTHeader, claims Map, key interface{}) string {
+	t.Helper()
+	encode := func(v interface{}) string {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	signed := encode(header) + "." + encode(claims)
+	digest := sha256.Sum256([]byte(signed))
+	var signature []byte
+	var err error
+	switch key := key.(type) {
+	case []byte:
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(signed))
+		signature = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		signature, err = rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	case *ecdsa.PrivateKey:
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, key, digest[:])
+		signature = make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+	case ed25519.PrivateKey:
+		signature = ed25519.Sign(key, []byte(signed))
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestJWTVerify(t *testing.T) {
+	secret := []byte("secret")
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	edPublic, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	now := time.Unix(1700000000, 0)
+	valid := Map{"sub": "42", "iss": "regia", "aud": []string{"api", "web"}, "exp": now.Unix() + 60, "nbf": now.Unix()}
+
+	tests := []struct {
+		name   string
+		config JWTConfig
+		token  string
+		err    error
+	}{
+		{"hs256", JWTConfig{Key: secret}, signJWT(t, JWTHeader{Algorithm: JWTHS256}, valid, secret), nil},
+		{"rs256", JWTConfig{Key: &rsaKey.PublicKey}, signJWT(t, JWTHeader{Algorithm: JWTRS256}, valid, rsaKey), nil},
+		{"es256", JWTConfig{Key: &ecKey.PublicKey}, signJWT(t, JWTHeader{Algorithm: JWTES256}, valid, ecKey), nil},
+		{"eddsa", JWTConfig{Key: edPublic}, signJWT(t, JWTHeader{Algorithm: JWTEdDSA}, valid, edKey), nil},
+		{"issuer and audience", JWTConfig{Key: secret, Issuer: "regia", Audience: "web"},
+			signJWT(t, JWTHeader{Algorithm: JWTHS256}, valid, secret), nil},
+		{"wrong secret", JWTConfig{Key: []byte("other")},
+			signJWT(t, JWTHeader{Algorithm: JWTHS256}, valid, secret), ErrTokenSignature},
+		{"algorithm confusion", JWTConfig{Key: &rsaKey.PublicKey},
+			signJWT(t, JWTHeader{Algorithm: JWTHS256}, valid, secret), ErrTokenKey},
+		{"none", JWTConfig{Key: secret},
+			signJWT(t, JWTHeader{Algorithm: "none"}, valid, nil), ErrTokenAlgorithm},
+		{"not allowed", JWTConfig{Key: secret, Algorithms: []string{JWTRS256}},
+			signJWT(t, JWTHeader{Algorithm: JWTHS256}, valid, secret), ErrTokenAlgorithm},
+		{"expired", JWTConfig{Key: secret},
+			signJWT(t, JWTHeader{Algorithm: JWTHS256}, Map{"exp": now.Unix()}, secret), ErrTokenExpired},
+		{"leeway", JWTConfig{Key: secret, Leeway: time.Minute},
+			signJWT(t, JWTHeader{Algorithm: JWTHS256}, Map{"exp": now.Unix()}, secret), nil},
+		{"not valid yet", JWTConfig{Key: secret},
+			signJWT(t, JWTHeader{Algorithm: JWTHS256}, Map{"nbf": now.Unix() + 10}, secret), ErrTokenNotValidYet},
+		{"issuer", JWTConfig{Key: secret, Issuer: "other"},
+			signJWT(t, JWTHeader{Algorithm: JWTHS256}, valid, secret), ErrTokenIssuer},
+		{"audience", JWTConfig{Key: secret, Audience: "admin"},
+			signJWT(t, JWTHeader{Algorithm: JWTHS256}, valid, secret), ErrTokenAudience},
+		{"malformed", JWTConfig{Key: secret}, "a.b", ErrTokenMalformed},
+	}
+	for _, test := range tests {
+		test.config.now = func() time.Time { return now }
+		if _, err := test.config.Verify(test.token); !errors.Is(err, test.err) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.err, err)
+		}
+	}
+
+	claims, _ := JWTConfig{Key: secret, now: func() time.Time { return now }}.
+		Verify(signJWT(t, JWTHeader{Algorithm: JWTHS256}, valid, secret))
+	if claims.Subject() != "42" || claims.Issuer() != "regia" || strings.Join(claims.Audience(), ",") != "api,web" ||
+		!claims.ExpiresAt().Equal(now.Add(time.Minute)) || !claims.NotBefore().Equal(now) {
+		t.Errorf("unexpected claims %v", claims)
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	edPublic, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	encode := base64.RawURLEncoding.EncodeToString
+	jwks := Map{"keys": []Map{
+		{"kty": "EC", "crv": "P-256", "kid": "ec", "alg": JWTES256,
+			"x": encode(ecKey.X.FillBytes(make([]byte, 32))), "y": encode(ecKey.Y.FillBytes(make([]byte, 32)))},
+		{"kty": "OKP", "crv": "Ed25519", "kid": "ed", "x": encode(edPublic)},
+		{"kty": "RSA", "kid": "enc", "use": "enc", "n": "AQAB", "e": "AQAB"},
+	}}
+	data, _ := json.Marshal(jwks)
+	name := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(name, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	engine := New()
+	engine.Use(JWT(JWTConfig{JWKS: JWKSFile(name)}))
+	engine.GET("/", func(c *Context) { _ = c.String(GetJWTClaims(c).Subject()) })
+	_ = engine.init()
+
+	claims := Map{"sub": "42", "exp": time.Now().Unix() + 60}
+	tests := []struct {
+		token     string
+		code      int
+		challenge string
+	}{
+		{signJWT(t, JWTHeader{Algorithm: JWTES256, KeyID: "ec"}, claims, ecKey), http.StatusOK, ""},
+		{signJWT(t, JWTHeader{Algorithm: JWTEdDSA, KeyID: "ed"}, claims, edKey), http.StatusOK, ""},
+		{signJWT(t, JWTHeader{Algorithm: JWTEdDSA}, claims, edKey), http.StatusOK, ""},
+		{signJWT(t, JWTHeader{Algorithm: JWTEdDSA, KeyID: "ec"}, claims, edKey), http.StatusUnauthorized,
+			`Bearer realm="Restricted", error="invalid_token", error_description="token key not found"`},
+		{signJWT(t, JWTHeader{Algorithm: JWTES256, KeyID: "ec"}, Map{"exp": time.Now().Unix() - 1}, ecKey),
+			http.StatusUnauthorized, `Bearer realm="Restricted", error="invalid_token", error_description="token expired"`},
+		{"", http.StatusUnauthorized, `Bearer realm="Restricted"`},
+	}
+	for _, test := range tests {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
+		if len(test.token) > 0 {
+			request.Header.Set("Authorization", "Bearer "+test.token)
+		}
+		engine.ServeHTTP(recorder, request)
+		if recorder.Code != test.code || recorder.Header().Get("WWW-Authenticate") != test.challenge {
+			t.Errorf("got %d %q", recorder.Code, recorder.Header().Get("WWW-Authenticate"))
+		}
+		if test.code == http.StatusOK && recorder.Body.String() != "42" {
+			t.Errorf("expected subject 42, got %q", recorder.Body.String())
+		}
+	}
+}
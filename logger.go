@@ -0,0 +1,318 @@
+// Copyright 2022 eatmoreapple.  All rights reserved.
+// Use of this source code is governed by a GPL style
+// license that can be found in the LICENSE file.
+
+package regia
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eatmoreapple/regia/internal"
+)
+
+const defaultRequestIDHeader = "X-Request-ID"
+
+// LogEntry is the access log of a request
+type LogEntry struct {
+	Time   time.Time
+	Method string
+	// Path is the path of request url
+	Path string
+	// FullPath is the registered route, such as /users/:id
+	// It is empty if no route matched
+	FullPath  string
+	Query     string
+	Proto     string
+	Status    int
+	Size      int
+	Latency   time.Duration
+	ClientIP  string
+	RequestID string
+	UserAgent string
+	Referer   string
+}
+
+// LogSink writes LogEntry to somewhere
+// It should be safe for concurrent use
+type LogSink interface {
+	Log(entry *LogEntry)
+}
+
+// LogSinkFunc is an adapter to allow the use of ordinary functions as LogSink
+type LogSinkFunc func(entry *LogEntry)
+
+func (f LogSinkFunc) Log(entry *LogEntry) { f(entry) }
+
+// TextLogSink writes LogEntry as a line of text
+//
+//	[REGIA] 2022/01/02 - 15:04:05 | 200 |   1.2ms | 127.0.0.1 | GET /users/:id /users/1 | 12B | request-id
+type TextLogSink struct {
+	Writer io.Writer
+	// Color colors the status and method with internal.FormatColor
+	Color bool
+	lock  sync.Mutex
+}
+
+func (t *TextLogSink) Log(entry *LogEntry) {
+	status, method := strconv.Itoa(entry.Status), escapeLogValue(entry.Method)
+	if t.Color {
+		status = internal.FormatColor(statusColor(entry.Status), status)
+		method = internal.FormatColor(methodColor(entry.Method), method)
+	}
+	path := entry.Path
+	if len(entry.Query) > 0 {
+		path += "?" + entry.Query
+	}
+	if len(entry.FullPath) > 0 && entry.FullPath != entry.Path {
+		path = entry.FullPath + " " + path
+	}
+	line := fmt.Sprintf("%s %s | %s | %12v | %15s | %s %s | %dB",
+		_regia, entry.Time.Format("2006/01/02 - 15:04:05"), status, entry.Latency,
+		escapeLogValue(entry.ClientIP), method, escapeLogValue(path), entry.Size)
+	if len(entry.RequestID) > 0 {
+		line += " | " + escapeLogValue(entry.RequestID)
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	_, _ = io.WriteString(t.Writer, line+"\n")
+}
+
+func statusColor(status int) int {
+	switch {
+	case status >= http.StatusInternalServerError:
+		return internal.Red
+	case status >= http.StatusBadRequest:
+		return internal.Yellow
+	case status >= http.StatusMultipleChoices:
+		return internal.Blue
+	default:
+		return internal.Green
+	}
+}
+
+func methodColor(method string) int {
+	switch method {
+	case http.MethodGet:
+		return internal.Blue
+	case http.MethodPost:
+		return internal.Green
+	case http.MethodPut, http.MethodPatch:
+		return internal.Yellow
+	case http.MethodDelete:
+		return internal.Red
+	default:
+		return internal.Magenta
+	}
+}
+
+// JSONLogSink writes LogEntry as a line of json
+type JSONLogSink struct {
+	Writer io.Writer
+	lock   sync.Mutex
+}
+
+func (j *JSONLogSink) Log(entry *LogEntry) {
+	data, err := json.Marshal(Map{
+		"time":       entry.Time.Format(time.RFC3339Nano),
+		"method":     entry.Method,
+		"path":       entry.Path,
+		"full_path":  entry.FullPath,
+		"query":      entry.Query,
+		"proto":      entry.Proto,
+		"status":     entry.Status,
+		"size":       entry.Size,
+		"latency":    entry.Latency.Seconds(),
+		"client_ip":  entry.ClientIP,
+		"request_id": entry.RequestID,
+		"user_agent": entry.UserAgent,
+		"referer":    entry.Referer,
+	})
+	if err != nil {
+		return
+	}
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	_, _ = j.Writer.Write(append(data, '\n'))
+}
+
+// CombinedLogSink writes LogEntry with Apache combined log format
+//
+//	127.0.0.1 - - [02/Jan/2006:15:04:05 -0700] "GET /users/1 HTTP/1.1" 200 12 "-" "curl/7.64.1"
+type CombinedLogSink struct {
+	Writer io.Writer
+	lock   sync.Mutex
+}
+
+func (c *CombinedLogSink) Log(entry *LogEntry) {
+	uri := entry.Path
+	if len(entry.Query) > 0 {
+		uri += "?" + entry.Query
+	}
+	size := "-"
+	if entry.Size > 0 {
+		size = strconv.Itoa(entry.Size)
+	}
+	line := fmt.Sprintf("%s - - [%s] \"%s %s %s\" %d %s %s %s\n",
+		orDash(escapeLogValue(entry.ClientIP)), entry.Time.Format("02/Jan/2006:15:04:05 -0700"),
+		escapeLogValue(entry.Method), escapeLogValue(uri), escapeLogValue(entry.Proto), entry.Status, size,
+		quoteOrDash(entry.Referer), quoteOrDash(entry.UserAgent))
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	_, _ = io.WriteString(c.Writer, line)
+}
+
+// escapeLogValue escapes control characters, quotes and backslashes as Apache does,
+// so that a request such as /a%0a can not forge another line of log
+func escapeLogValue(s string) string {
+	var builder strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch b := s[i]; {
+		case b == '"' || b == '\\':
+			builder.WriteByte('\\')
+			builder.WriteByte(b)
+		case b < 0x20 || b == 0x7f:
+			_, _ = fmt.Fprintf(&builder, "\\x%02x", b)
+		default:
+			builder.WriteByte(b)
+		}
+	}
+	return builder.String()
+}
+
+func orDash(s string) string {
+	if len(s) == 0 {
+		return "-"
+	}
+	return s
+}
+
+func quoteOrDash(s string) string {
+	if len(s) == 0 {
+		return `"-"`
+	}
+	return strconv.Quote(s)
+}
+
+// SlogLogSink writes LogEntry with slog.Logger
+// The level is Error for 5xx, Warn for 4xx and Info for the others
+type SlogLogSink struct {
+	// Logger uses slog.Default if nil
+	Logger *slog.Logger
+	// Message is the message of log record, default "request"
+	Message string
+}
+
+func (s SlogLogSink) Log(entry *LogEntry) {
+	logger := s.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	message := s.Message
+	if len(message) == 0 {
+		message = "request"
+	}
+	level := slog.LevelInfo
+	switch {
+	case entry.Status >= http.StatusInternalServerError:
+		level = slog.LevelError
+	case entry.Status >= http.StatusBadRequest:
+		level = slog.LevelWarn
+	}
+	logger.LogAttrs(context.Background(), level, message,
+		slog.String("method", entry.Method),
+		slog.String("path", entry.Path),
+		slog.String("full_path", entry.FullPath),
+		slog.String("query", entry.Query),
+		slog.Int("status", entry.Status),
+		slog.Int("size", entry.Size),
+		slog.Duration("latency", entry.Latency),
+		slog.String("client_ip", entry.ClientIP),
+		slog.String("request_id", entry.RequestID),
+		slog.String("user_agent", entry.UserAgent),
+	)
+}
+
+// LoggerConfig is the config of Logger
+type LoggerConfig struct {
+	// Sink uses TextLogSink with os.Stdout if nil, colored only if os.Stdout is a terminal
+	Sink LogSink
+	// SkipPaths are not logged, they match both request path and FullPath
+	SkipPaths []string
+	// Skip reports whether the entry should not be logged, such as by status
+	Skip func(c *Context, entry *LogEntry) bool
+	// RequestIDHeader is the header of request id, default X-Request-ID
+	// The request header is used first, then the response header
+	RequestIDHeader string
+}
+
+// Logger returns a middleware which writes access log of every request
+// Add it by Engine.AddInterceptors to log the requests of unmatched routes too
+//
+//	engine.AddInterceptors(regia.Logger(regia.LoggerConfig{
+//		Sink: &regia.JSONLogSink{Writer: os.Stdout},
+//		Skip: func(c *regia.Context, entry *regia.LogEntry) bool { return entry.Status < 400 },
+//	}))
+func Logger(config LoggerConfig) HandleFunc {
+	sink := config.Sink
+	if sink == nil {
+		sink = &TextLogSink{Writer: os.Stdout, Color: internal.ColorEnabled(os.Stdout)}
+	}
+	requestIDHeader := config.RequestIDHeader
+	if len(requestIDHeader) == 0 {
+		requestIDHeader = defaultRequestIDHeader
+	}
+	skipPaths := make(map[string]struct{}, len(config.SkipPaths))
+	for _, path := range config.SkipPaths {
+		skipPaths[path] = struct{}{}
+	}
+	return func(c *Context) {
+		if _, skip := skipPaths[c.Request.URL.Path]; skip {
+			return
+		}
+		if _, skip := skipPaths[c.FullPath()]; skip {
+			return
+		}
+		start := time.Now()
+		writer := c.recordResponse()
+		c.Next()
+
+		status := writer.Status()
+		if status == 0 {
+			// header will be written by Context.finish
+			status = c.Status()
+		}
+		requestID := c.Request.Header.Get(requestIDHeader)
+		if len(requestID) == 0 {
+			requestID = writer.Header().Get(requestIDHeader)
+		}
+		entry := &LogEntry{
+			Time:      start,
+			Method:    c.Request.Method,
+			Path:      c.Request.URL.Path,
+			FullPath:  c.FullPath(),
+			Query:     c.Request.URL.RawQuery,
+			Proto:     c.Request.Proto,
+			Status:    status,
+			Size:      writer.Size(),
+			Latency:   time.Since(start),
+			ClientIP:  c.RemoteIP(),
+			RequestID: requestID,
+			UserAgent: c.Request.UserAgent(),
+			Referer:   strings.TrimSpace(c.Request.Referer()),
+		}
+		if config.Skip != nil && config.Skip(c, entry) {
+			return
+		}
+		sink.Log(entry)
+	}
+}
@@ -0,0 +1,123 @@
+package regia
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/eatmoreapple/regia/internal"
+)
+
+func TestLogger(t *testing.T) {
+	var entries []*LogEntry
+	engine := New()
+	engine.AddInterceptors(Logger(LoggerConfig{
+		Sink:      LogSinkFunc(func(entry *LogEntry) { entries = append(entries, entry) }),
+		SkipPaths: []string{"/health"},
+		Skip:      func(c *Context, entry *LogEntry) bool { return entry.Status == http.StatusNoContent },
+	}))
+	engine.GET("/users/:id", func(c *Context) { _ = c.String("user " + c.Params().Get("id")) })
+	engine.GET("/health", func(c *Context) { _ = c.String("ok") })
+	engine.GET("/empty", func(c *Context) { c.SetStatus(http.StatusNoContent) })
+	engine.POST("/users", func(c *Context) { c.AbortWithError(NewHttpError(http.StatusConflict, "")) })
+	_ = engine.init()
+
+	for _, r := range []struct{ method, path string }{
+		{http.MethodGet, "/users/1?x=1"},
+		{http.MethodGet, "/health"},
+		{http.MethodGet, "/empty"},
+		{http.MethodPost, "/users"},
+		{http.MethodGet, "/missing"},
+	} {
+		req := httptest.NewRequest(r.method, r.path, nil)
+		req.Header.Set("X-Request-ID", "id-"+r.path)
+		engine.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(entries))
+	}
+	first := entries[0]
+	if first.FullPath != "/users/:id" || first.Path != "/users/1" || first.Query != "x=1" ||
+		first.Status != http.StatusOK || first.Size != len("user 1") || first.RequestID != "id-/users/1?x=1" {
+		t.Errorf("unexpected entry %+v", first)
+	}
+	if entries[1].Status != http.StatusConflict || entries[1].FullPath != "/users" {
+		t.Errorf("unexpected entry %+v", entries[1])
+	}
+	if entries[2].Status != http.StatusNotFound || entries[2].FullPath != "" {
+		t.Errorf("unexpected entry %+v", entries[2])
+	}
+}
+
+func TestLogSinks(t *testing.T) {
+	entry := &LogEntry{
+		Method:    http.MethodGet,
+		Path:      "/users/1",
+		FullPath:  "/users/:id",
+		Proto:     "HTTP/1.1",
+		Status:    http.StatusOK,
+		Size:      12,
+		ClientIP:  "127.0.0.1",
+		UserAgent: "curl/7.64.1",
+	}
+	var buf bytes.Buffer
+	(&CombinedLogSink{Writer: &buf}).Log(entry)
+	if !strings.HasPrefix(buf.String(), "127.0.0.1 - - [") ||
+		!strings.HasSuffix(buf.String(), `"GET /users/1 HTTP/1.1" 200 12 "-" "curl/7.64.1"`+"\n") {
+		t.Errorf("unexpected combined log %q", buf.String())
+	}
+
+	buf.Reset()
+	(&JSONLogSink{Writer: &buf}).Log(entry)
+	var v map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &v); err != nil || v["full_path"] != "/users/:id" || v["status"] != float64(200) {
+		t.Errorf("unexpected json log %q", buf.String())
+	}
+
+	buf.Reset()
+	(&TextLogSink{Writer: &buf}).Log(entry)
+	if !strings.Contains(buf.String(), "| 200 |") || !strings.Contains(buf.String(), "GET /users/:id /users/1 | 12B") {
+		t.Errorf("unexpected text log %q", buf.String())
+	}
+}
+
+func TestLogSinksEscape(t *testing.T) {
+	var text, combined bytes.Buffer
+	engine := New()
+	engine.AddInterceptors(
+		Logger(LoggerConfig{Sink: &TextLogSink{Writer: &text}}),
+		Logger(LoggerConfig{Sink: &CombinedLogSink{Writer: &combined}}),
+	)
+	_ = engine.init()
+
+	// try to forge another line of log
+	path := "/a%0a127.0.0.1%20-%20-%20[02/Jan/2006:15:04:05%20-0700]%20%22GET%20/admin%20HTTP/1.1%22%20200%0d"
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	for name, buf := range map[string]*bytes.Buffer{"text": &text, "combined": &combined} {
+		line := buf.String()
+		if strings.Count(line, "\n") != 1 || strings.ContainsRune(line, '\r') {
+			t.Errorf("%s: log line is forged %q", name, line)
+		}
+		if !strings.Contains(line, `/a\x0a127.0.0.1 - - [02/Jan/2006:15:04:05 -0700] \"GET /admin HTTP/1.1\" 200\x0d`) {
+			t.Errorf("%s: path is not escaped %q", name, line)
+		}
+	}
+}
+
+func TestColorEnabled(t *testing.T) {
+	file, err := os.CreateTemp(t.TempDir(), "log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	for _, writer := range []io.Writer{&bytes.Buffer{}, file} {
+		if internal.ColorEnabled(writer) {
+			t.Errorf("%T is not a terminal", writer)
+		}
+	}
+}
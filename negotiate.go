@@ -0,0 +1,141 @@
+// Copyright 2022 eatmoreapple.  All rights reserved.
+// Use of this source code is governed by a GPL style
+// license that can be found in the LICENSE file.
+
+package regia
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// acceptSpec is a single item of header like Accept or Accept-Encoding
+type acceptSpec struct {
+	value string
+	q     float64
+}
+
+// parseAccept parses Accept-like header and returns its items sorted by q-value
+// Items with same q-value keep their original order
+func parseAccept(header string) []acceptSpec {
+	var specs []acceptSpec
+	for _, part := range strings.Split(header, ",") {
+		part = strings.TrimSpace(part)
+		if len(part) == 0 {
+			continue
+		}
+		spec := acceptSpec{q: 1}
+		params := strings.Split(part, ";")
+		spec.value = strings.ToLower(strings.TrimSpace(params[0]))
+		for _, param := range params[1:] {
+			param = strings.TrimSpace(param)
+			if !strings.HasPrefix(param, "q=") {
+				continue
+			}
+			q, err := strconv.ParseFloat(param[2:], 64)
+			if err != nil || q < 0 || q > 1 {
+				q = 0
+			}
+			spec.q = q
+		}
+		specs = append(specs, spec)
+	}
+	sort.SliceStable(specs, func(i, j int) bool { return specs[i].q > specs[j].q })
+	return specs
+}
+
+// matchMediaType reports whether the media range of Accept header matches given media type
+func matchMediaType(mediaRange, mediaType string) bool {
+	if mediaRange == "*/*" || mediaRange == mediaType {
+		return true
+	}
+	if strings.HasSuffix(mediaRange, "/*") {
+		return strings.HasPrefix(mediaType, mediaRange[:len(mediaRange)-1])
+	}
+	return false
+}
+
+// mediaRangeSpecificity returns how specific the media range is,
+// -1 will be returned if it does not match given media type
+func mediaRangeSpecificity(mediaRange, mediaType string) int {
+	if !matchMediaType(mediaRange, mediaType) {
+		return -1
+	}
+	switch {
+	case mediaRange == "*/*":
+		return 0
+	case strings.HasSuffix(mediaRange, "/*"):
+		return 1
+	default:
+		return 2
+	}
+}
+
+// refusedMediaType reports whether the most specific media range matching given media type has q=0
+// such as application/json in "application/json;q=0, */*"
+func refusedMediaType(specs []acceptSpec, mediaType string) bool {
+	specificity, refused := -1, false
+	for _, spec := range specs {
+		if s := mediaRangeSpecificity(spec.value, mediaType); s > specificity {
+			specificity, refused = s, spec.q == 0
+		}
+	}
+	return refused
+}
+
+// NegotiateFormat returns the best offered media type for the Accept header
+// The first offer will be returned if the Accept header is empty
+// An empty string will be returned if nothing acceptable
+// Offers refused explicitly with q=0 are never returned, even if a wildcard matches them
+func (c *Context) NegotiateFormat(offers ...string) string {
+	if len(offers) == 0 {
+		return ""
+	}
+	header := c.Request.Header.Get("Accept")
+	if len(header) == 0 {
+		return offers[0]
+	}
+	specs := parseAccept(header)
+	for _, spec := range specs {
+		if spec.q == 0 {
+			continue
+		}
+		for _, offer := range offers {
+			mediaType := strings.ToLower(offer)
+			if matchMediaType(spec.value, mediaType) && !refusedMediaType(specs, mediaType) {
+				return offer
+			}
+		}
+	}
+	return ""
+}
+
+// Negotiate write response with the format chosen by the Accept header
+// Supports json, xml, yaml, toml, msgpack, cbor and protobuf
+// protobuf would be only offered when data implements proto.Message
+// Response will be written as json if nothing acceptable
+func (c *Context) Negotiate(data interface{}) error {
+	offers := []string{mimeJson, mimeXml, mimeXml2, mimeYaml, mimeYaml2, mimeToml, mimeMsgPack, mimeCbor}
+	if _, ok := data.(proto.Message); ok {
+		offers = append(offers, mimeProtobuf, mimeProtobuf2)
+	}
+	switch c.NegotiateFormat(offers...) {
+	case mimeXml, mimeXml2:
+		return c.XML(data)
+	case mimeYaml, mimeYaml2:
+		return c.YAML(data)
+	case mimeToml:
+		return c.TOML(data)
+	case mimeMsgPack:
+		return c.MsgPack(data)
+	case mimeCbor:
+		return c.CBOR(data)
+	case mimeProtobuf, mimeProtobuf2:
+		return c.Protobuf(data)
+	default:
+		return c.JSON(data)
+	}
+}
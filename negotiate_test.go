@@ -0,0 +1,94 @@
+package regia
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/eatmoreapple/regia/serializers"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestNegotiateFormat(t *testing.T) {
+	cases := []struct {
+		accept string
+		want   string
+	}{
+		{"", mimeJson},
+		{"*/*", mimeJson},
+		{"application/xml", mimeXml},
+		{"application/json;q=0.5, application/x-protobuf", mimeProtobuf},
+		{"text/*", mimeXml2},
+		{"application/json;q=0", ""},
+		// an explicit refusal takes priority over wildcards
+		{"application/json;q=0, */*", mimeXml},
+		{"*/*, application/json;q=0, application/xml;q=0", mimeXml2},
+		{"text/*;q=0, application/*", mimeJson},
+		{"application/*;q=0, application/xml", mimeXml},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Accept", c.accept)
+		ctx := &Context{Request: req}
+		if got := ctx.NegotiateFormat(mimeJson, mimeXml, mimeXml2, mimeProtobuf); got != c.want {
+			t.Errorf("NegotiateFormat(%q) = %q, want %q", c.accept, got, c.want)
+		}
+	}
+}
+
+func TestProtobuf(t *testing.T) {
+	engine := New()
+	engine.POST("/", func(c *Context) {
+		var message wrapperspb.StringValue
+		if err := c.Data(&message); err != nil {
+			c.SetStatus(http.StatusBadRequest)
+			c.AbortWithString(err.Error())
+			return
+		}
+		message.Value += "!"
+		_ = c.Negotiate(&message)
+	})
+	engine.POST("/struct", func(c *Context) {
+		var v struct{ Value string }
+		if err := c.BindProtobuf(&v); !errors.Is(err, serializers.ErrNotProtoMessage) {
+			t.Errorf("expected ErrNotProtoMessage, got %v", err)
+		}
+	})
+	_ = engine.init()
+
+	body, err := proto.Marshal(wrapperspb.String("regia"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, accept := range []string{mimeProtobuf, mimeProtobuf2} {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+		request.Header.Set("Content-Type", mimeProtobuf2)
+		request.Header.Set("Accept", accept)
+		engine.ServeHTTP(recorder, request)
+		var got wrapperspb.StringValue
+		if err = proto.Unmarshal(recorder.Body.Bytes(), &got); err != nil || got.Value != "regia!" {
+			t.Errorf("%s: expected regia!, got %q %v", accept, got.Value, err)
+		}
+		if recorder.Header().Get("Content-Type") != mimeProtobuf {
+			t.Errorf("%s: unexpected content type %q", accept, recorder.Header().Get("Content-Type"))
+		}
+	}
+
+	// protobuf is only offered for proto.Message
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	request.Header.Set("Content-Type", mimeProtobuf)
+	engine.ServeHTTP(recorder, request)
+	if strings.TrimSpace(recorder.Body.String()) != `{"value":"regia!"}` {
+		t.Errorf("expected json without Accept, got %q", recorder.Body.String())
+	}
+
+	request = httptest.NewRequest(http.MethodPost, "/struct", bytes.NewReader(body))
+	request.Header.Set("Content-Type", mimeProtobuf)
+	engine.ServeHTTP(httptest.NewRecorder(), request)
+}
@@ -6,6 +6,8 @@ package regia
 
 import (
 	"strings"
+
+	"github.com/eatmoreapple/regia/binders"
 )
 
 const (
@@ -16,6 +18,17 @@ const (
 	mimeXml               = "application/xml"
 	mimeXml2              = "text/xml"
 	mimeHtml              = "text/html"
+	mimeYaml              = "application/x-yaml"
+	mimeYaml2             = "application/yaml"
+	mimeYaml3             = "text/yaml"
+	mimeToml              = "application/toml"
+	mimeMsgPack           = "application/msgpack"
+	mimeMsgPack2          = "application/x-msgpack"
+	mimeCbor              = "application/cbor"
+	mimeProtobuf          = "application/x-protobuf"
+	mimeProtobuf2         = "application/protobuf"
+	mimeCsv               = "text/csv"
+	mimeCsv2              = "application/csv"
 )
 
 type Parser interface {
@@ -81,6 +94,85 @@ func (x XMLParser) Match(context *Context) bool {
 		strings.Contains(strings.ToLower(context.ContentType()), mimeXml2)
 }
 
+// YAMLParser Parses YAML-serialized data.
+type YAMLParser struct{}
+
+func (y YAMLParser) Parse(context *Context, v interface{}) error {
+	return context.BindYAML(v)
+}
+
+func (y YAMLParser) Match(context *Context) bool {
+	contentType := strings.ToLower(context.ContentType())
+	return strings.Contains(contentType, mimeYaml) ||
+		strings.Contains(contentType, mimeYaml2) ||
+		strings.Contains(contentType, mimeYaml3)
+}
+
+// TOMLParser Parses TOML-serialized data.
+type TOMLParser struct{}
+
+func (t TOMLParser) Parse(context *Context, v interface{}) error {
+	return context.BindTOML(v)
+}
+
+func (t TOMLParser) Match(context *Context) bool {
+	return strings.Contains(strings.ToLower(context.ContentType()), mimeToml)
+}
+
+// MsgPackParser Parses MessagePack-serialized data.
+type MsgPackParser struct{}
+
+func (m MsgPackParser) Parse(context *Context, v interface{}) error {
+	return context.BindMsgPack(v)
+}
+
+func (m MsgPackParser) Match(context *Context) bool {
+	contentType := strings.ToLower(context.ContentType())
+	return strings.Contains(contentType, mimeMsgPack) ||
+		strings.Contains(contentType, mimeMsgPack2)
+}
+
+// CBORParser Parses CBOR-serialized data.
+type CBORParser struct{}
+
+func (c CBORParser) Parse(context *Context, v interface{}) error {
+	return context.BindCBOR(v)
+}
+
+func (c CBORParser) Match(context *Context) bool {
+	return strings.Contains(strings.ToLower(context.ContentType()), mimeCbor)
+}
+
+// ProtobufParser Parses Protocol Buffers data into proto.Message.
+type ProtobufParser struct{}
+
+func (p ProtobufParser) Parse(context *Context, v interface{}) error {
+	return context.BindProtobuf(v)
+}
+
+func (p ProtobufParser) Match(context *Context) bool {
+	contentType := strings.ToLower(context.ContentType())
+	return strings.Contains(contentType, mimeProtobuf) ||
+		strings.Contains(contentType, mimeProtobuf2)
+}
+
+// CSVParser Parses uploaded csv rows into a slice of structs.
+type CSVParser struct {
+	// Comma is the field delimiter, default is ','
+	Comma rune
+}
+
+func (c CSVParser) Parse(context *Context, v interface{}) error {
+	binder := binders.CSVBodyBinder{Comma: c.Comma}
+	return context.bindBody(binder, v)
+}
+
+func (c CSVParser) Match(context *Context) bool {
+	contentType := strings.ToLower(context.ContentType())
+	return strings.Contains(contentType, mimeCsv) ||
+		strings.Contains(contentType, mimeCsv2)
+}
+
 type QueryParser struct{}
 
 func (q QueryParser) Parse(context *Context, v interface{}) error {
@@ -0,0 +1,79 @@
+package regia
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/eatmoreapple/regia/serializers"
+)
+
+type parserItem struct {
+	Name  string
+	Count int
+}
+
+func TestParsers(t *testing.T) {
+	engine := New()
+	renders := map[string]func(c *Context, data interface{}) error{
+		"yaml":    (*Context).YAML,
+		"toml":    (*Context).TOML,
+		"msgpack": (*Context).MsgPack,
+		"cbor":    (*Context).CBOR,
+	}
+	engine.POST("/:format", func(c *Context) {
+		var item parserItem
+		if err := c.Data(&item); err != nil {
+			c.SetStatus(http.StatusBadRequest)
+			c.AbortWithString(err.Error())
+			return
+		}
+		_ = renders[c.Params().Get("format")](c, item)
+	})
+	_ = engine.init()
+
+	tests := []struct {
+		format      string
+		contentType string
+		serializer  serializers.Serializer
+	}{
+		{"yaml", "application/x-yaml", serializers.YamlSerializer{}},
+		{"yaml", "application/yaml", serializers.YamlSerializer{}},
+		{"yaml", "text/yaml", serializers.YamlSerializer{}},
+		{"toml", "application/toml", serializers.TomlSerializer{}},
+		{"msgpack", "application/msgpack", serializers.MsgPackSerializer{}},
+		{"msgpack", "application/x-msgpack", serializers.MsgPackSerializer{}},
+		{"cbor", "application/cbor", serializers.CborSerializer{}},
+	}
+	contentTypes := map[string]string{
+		"yaml":    "application/x-yaml",
+		"toml":    "application/toml",
+		"msgpack": "application/msgpack",
+		"cbor":    "application/cbor",
+	}
+	want := parserItem{Name: "regia", Count: 3}
+	for _, test := range tests {
+		var body bytes.Buffer
+		if err := test.serializer.Encode(&body, want); err != nil {
+			t.Fatal(err)
+		}
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodPost, "/"+test.format, &body)
+		request.Header.Set("Content-Type", test.contentType)
+		engine.ServeHTTP(recorder, request)
+		if recorder.Code != http.StatusOK {
+			t.Errorf("%s: expected 200, got %d %s", test.contentType, recorder.Code, recorder.Body.String())
+			continue
+		}
+		if !strings.HasPrefix(recorder.Header().Get("Content-Type"), contentTypes[test.format]) {
+			t.Errorf("%s: unexpected content type %q", test.contentType, recorder.Header().Get("Content-Type"))
+		}
+		var got parserItem
+		if err := test.serializer.Decode(recorder.Body, &got); err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: expected %v, got %v %v", test.contentType, want, got, err)
+		}
+	}
+}
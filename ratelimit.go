@@ -0,0 +1,259 @@
+// Copyright 2022 eatmoreapple.  All rights reserved.
+// Use of this source code is governed by a GPL style
+// license that can be found in the LICENSE file.
+
+package regia
+
+import (
+	"hash/fnv"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrTooManyRequests returned when the request exceeds the rate limit
+var ErrTooManyRequests = NewHttpError(http.StatusTooManyRequests, "")
+
+// RateLimitResult is the result of taking a request from RateLimitStore
+type RateLimitResult struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is how long until the quota is fully restored
+	Reset time.Duration
+	// RetryAfter is how long until the next request may be allowed
+	// It is zero if allowed
+	RetryAfter time.Duration
+}
+
+// RateLimitStore records the requests of keys
+// Implement it with external backend, such as redis, to share limits between instances
+type RateLimitStore interface {
+	// Take takes a request of key which is limited to limit requests per window
+	Take(key string, limit int, window time.Duration) (RateLimitResult, error)
+}
+
+// RateLimitAlgorithm is the algorithm used by MemoryRateLimitStore
+type RateLimitAlgorithm uint8
+
+const (
+	// TokenBucket refills the quota continuously and allows burst up to limit
+	TokenBucket RateLimitAlgorithm = iota
+	// SlidingWindow weights the count of previous window by its overlap with the sliding window
+	SlidingWindow
+)
+
+const (
+	rateLimitShards     = 64
+	rateLimitSweepEvery = 1024
+)
+
+// MemoryRateLimitStore is a RateLimitStore in memory
+// Keys are distributed to shards to reduce lock contention,
+// and expired keys are evicted while taking
+type MemoryRateLimitStore struct {
+	algorithm RateLimitAlgorithm
+	shards    [rateLimitShards]rateLimitShard
+	// now is used to get current time, replaceable in test
+	now func() time.Time
+}
+
+type rateLimitShard struct {
+	lock    sync.Mutex
+	entries map[string]*rateLimitEntry
+	takes   int
+}
+
+type rateLimitEntry struct {
+	// tokens and last are used by TokenBucket
+	tokens float64
+	last   time.Time
+	// windowStart, current and previous are used by SlidingWindow
+	windowStart time.Time
+	current     int
+	previous    int
+	// expire is when the entry can be evicted
+	expire time.Time
+}
+
+// NewMemoryRateLimitStore constructor for MemoryRateLimitStore
+func NewMemoryRateLimitStore(algorithm RateLimitAlgorithm) *MemoryRateLimitStore {
+	store := &MemoryRateLimitStore{algorithm: algorithm, now: time.Now}
+	for i := range store.shards {
+		store.shards[i].entries = make(map[string]*rateLimitEntry)
+	}
+	return store
+}
+
+// Take implements RateLimitStore
+func (m *MemoryRateLimitStore) Take(key string, limit int, window time.Duration) (RateLimitResult, error) {
+	now := m.now()
+	shard := &m.shards[shardIndex(key)]
+	shard.lock.Lock()
+	defer shard.lock.Unlock()
+
+	shard.takes++
+	if shard.takes%rateLimitSweepEvery == 0 {
+		shard.sweep(now)
+	}
+	entry, exist := shard.entries[key]
+	if !exist {
+		entry = &rateLimitEntry{tokens: float64(limit), last: now, windowStart: now}
+		shard.entries[key] = entry
+	}
+	if m.algorithm == SlidingWindow {
+		return entry.slidingWindow(now, limit, window), nil
+	}
+	return entry.tokenBucket(now, limit, window), nil
+}
+
+// Len returns the count of keys recorded
+func (m *MemoryRateLimitStore) Len() int {
+	var count int
+	for i := range m.shards {
+		m.shards[i].lock.Lock()
+		count += len(m.shards[i].entries)
+		m.shards[i].lock.Unlock()
+	}
+	return count
+}
+
+func shardIndex(key string) uint32 {
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(key))
+	return hash.Sum32() % rateLimitShards
+}
+
+// sweep evicts the expired entries
+func (s *rateLimitShard) sweep(now time.Time) {
+	for key, entry := range s.entries {
+		if now.After(entry.expire) {
+			delete(s.entries, key)
+		}
+	}
+}
+
+func (e *rateLimitEntry) tokenBucket(now time.Time, limit int, window time.Duration) RateLimitResult {
+	// tokens refilled per second
+	rate := float64(limit) / window.Seconds()
+	elapsed := now.Sub(e.last).Seconds()
+	if elapsed > 0 {
+		e.tokens = math.Min(float64(limit), e.tokens+elapsed*rate)
+		e.last = now
+	}
+	result := RateLimitResult{Limit: limit}
+	if e.tokens >= 1 {
+		e.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = secondsToDuration((1 - e.tokens) / rate)
+	}
+	result.Remaining = int(e.tokens)
+	result.Reset = secondsToDuration((float64(limit) - e.tokens) / rate)
+	e.expire = now.Add(result.Reset)
+	return result
+}
+
+func (e *rateLimitEntry) slidingWindow(now time.Time, limit int, window time.Duration) RateLimitResult {
+	// move to the window of now
+	if elapsed := now.Sub(e.windowStart); elapsed >= window {
+		windows := elapsed / window
+		if windows == 1 {
+			e.previous = e.current
+		} else {
+			e.previous = 0
+		}
+		e.current = 0
+		e.windowStart = e.windowStart.Add(windows * window)
+	}
+	elapsed := now.Sub(e.windowStart)
+	weight := 1 - float64(elapsed)/float64(window)
+	estimated := float64(e.previous)*weight + float64(e.current)
+
+	result := RateLimitResult{Limit: limit, Reset: window - elapsed}
+	if estimated+1 <= float64(limit) {
+		e.current++
+		estimated++
+		result.Allowed = true
+	} else {
+		// wait until the weighted previous count decreases enough
+		retry := window - elapsed
+		if free := float64(limit - 1 - e.current); e.previous > 0 && free >= 0 {
+			retry = window - elapsed - time.Duration(free*float64(window)/float64(e.previous))
+		}
+		if retry < 0 {
+			retry = 0
+		}
+		result.RetryAfter = retry
+	}
+	result.Remaining = limit - int(math.Ceil(estimated))
+	if result.Remaining < 0 {
+		result.Remaining = 0
+	}
+	e.expire = e.windowStart.Add(2 * window)
+	return result
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// RateLimitConfig is the config of RateLimit
+type RateLimitConfig struct {
+	// Limit is the max requests of a key per Window
+	Limit  int
+	Window time.Duration
+	// Key returns the key to limit, default Context.RemoteIP
+	Key func(c *Context) string
+	// Store uses MemoryRateLimitStore with TokenBucket if nil
+	Store RateLimitStore
+	// Skip reports whether the request should not be limited
+	Skip func(c *Context) bool
+}
+
+// RateLimit returns a middleware which limits the requests of each key
+// RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers will be set,
+// and the rejected request will be replied 429 with Retry-After by Engine.ErrorHandle
+//
+//	engine.AddInterceptors(regia.RateLimit(regia.RateLimitConfig{Limit: 100, Window: time.Minute}))
+func RateLimit(config RateLimitConfig) HandleFunc {
+	if config.Limit <= 0 || config.Window <= 0 {
+		panic("rate limit and window must be positive")
+	}
+	key := config.Key
+	if key == nil {
+		key = func(c *Context) string { return c.RemoteIP() }
+	}
+	store := config.Store
+	if store == nil {
+		store = NewMemoryRateLimitStore(TokenBucket)
+	}
+	policy := strconv.Itoa(config.Limit) + ";w=" + strconv.Itoa(int(math.Ceil(config.Window.Seconds())))
+
+	return func(c *Context) {
+		if config.Skip != nil && config.Skip(c) {
+			return
+		}
+		result, err := store.Take(key(c), config.Limit, config.Window)
+		if err != nil {
+			c.AbortWithError(err)
+			return
+		}
+		header := c.ResponseWriter.Header()
+		header.Set("RateLimit-Policy", policy)
+		header.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+		header.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		header.Set("RateLimit-Reset", ceilSeconds(result.Reset))
+		if !result.Allowed {
+			header.Set("Retry-After", ceilSeconds(result.RetryAfter))
+			c.AbortWithError(ErrTooManyRequests)
+		}
+	}
+}
+
+// ceilSeconds returns the seconds of duration rounded up
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
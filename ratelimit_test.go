@@ -0,0 +1,64 @@
+package regia
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestMemoryRateLimitStore(t *testing.T) {
+	now := time.Unix(1000, 0)
+	for _, algorithm := range []RateLimitAlgorithm{TokenBucket, SlidingWindow} {
+		store := NewMemoryRateLimitStore(algorithm)
+		store.now = func() time.Time { return now }
+		for i := 0; i < 3; i++ {
+			if result, _ := store.Take("a", 3, time.Minute); !result.Allowed || result.Remaining != 2-i {
+				t.Fatalf("algorithm %d: request %d should be allowed, got %+v", algorithm, i, result)
+			}
+		}
+		result, _ := store.Take("a", 3, time.Minute)
+		if result.Allowed || result.RetryAfter <= 0 || result.RetryAfter > time.Minute {
+			t.Errorf("algorithm %d: request should be rejected, got %+v", algorithm, result)
+		}
+		if result, _ = store.Take("b", 3, time.Minute); !result.Allowed {
+			t.Errorf("algorithm %d: other key should be allowed", algorithm)
+		}
+		now = now.Add(result.Reset + time.Minute)
+		if result, _ = store.Take("a", 3, time.Minute); !result.Allowed {
+			t.Errorf("algorithm %d: request should be allowed after reset, got %+v", algorithm, result)
+		}
+		for i := range store.shards {
+			store.shards[i].sweep(now.Add(3 * time.Minute))
+		}
+		if store.Len() != 0 {
+			t.Errorf("algorithm %d: expired keys should be evicted, got %d", algorithm, store.Len())
+		}
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	engine := New()
+	engine.AddInterceptors(RateLimit(RateLimitConfig{Limit: 2, Window: time.Minute}))
+	engine.GET("/", func(c *Context) { _ = c.String("ok") })
+	_ = engine.init()
+
+	for i := 0; i < 3; i++ {
+		recorder := httptest.NewRecorder()
+		engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+		header := recorder.Header()
+		if header.Get("RateLimit-Limit") != "2" || header.Get("RateLimit-Policy") != "2;w=60" {
+			t.Errorf("unexpected headers %v", header)
+		}
+		if i < 2 {
+			if recorder.Code != http.StatusOK || header.Get("RateLimit-Remaining") != strconv.Itoa(1-i) {
+				t.Errorf("request %d: got %d %v", i, recorder.Code, header)
+			}
+			continue
+		}
+		if recorder.Code != http.StatusTooManyRequests || header.Get("Retry-After") != "30" {
+			t.Errorf("request %d: got %d %v", i, recorder.Code, header)
+		}
+	}
+}
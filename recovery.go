@@ -0,0 +1,124 @@
+// Copyright 2022 eatmoreapple.  All rights reserved.
+// Use of this source code is governed by a GPL style
+// license that can be found in the LICENSE file.
+
+package regia
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"runtime/debug"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// PanicError is the error recovered from panic
+type PanicError struct {
+	Value interface{}
+	// Stack is the stack trace of panic, it is nil if RecoveryConfig.DisableStack
+	Stack []byte
+}
+
+func (p *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", p.Value)
+}
+
+// Unwrap returns the panic value if it is an error
+func (p *PanicError) Unwrap() error {
+	err, _ := p.Value.(error)
+	return err
+}
+
+// RecoveryConfig is the config of Recovery
+type RecoveryConfig struct {
+	// Writer is where the panic and stack are written, default os.Stderr
+	Writer io.Writer
+	// DisableStack does not capture the stack trace
+	DisableStack bool
+	// Handle replies to the request after recovered
+	// It is not called if the response has been written or the connection is broken
+	// Default replies 500 by Context.AbortWithError
+	Handle func(c *Context, err *PanicError)
+}
+
+// Recovery returns a middleware which recovers from panics and replies 500 by Engine.ErrorHandle
+func Recovery() HandleFunc {
+	return RecoveryWithConfig(RecoveryConfig{})
+}
+
+// RecoveryWithConfig returns a Recovery middleware with config
+// http.ErrAbortHandler will be panicked again to abort the response as net/http does
+// If the connection is broken, such as broken pipe, or the response has been written,
+// only the panic is logged and the rest handlers are aborted
+func RecoveryWithConfig(config RecoveryConfig) HandleFunc {
+	writer := config.Writer
+	if writer == nil {
+		writer = os.Stderr
+	}
+	handle := config.Handle
+	if handle == nil {
+		handle = func(c *Context, err *PanicError) {
+			c.AbortWithError(&HttpError{Code: http.StatusInternalServerError, Message: "panic recovered", Err: err})
+		}
+	}
+	return func(c *Context) {
+		recorder := c.recordResponse()
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			// let net/http abort the response silently
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+			// panic of Timeout has been wrapped with the stack of its goroutine
+			panicErr, ok := rec.(*PanicError)
+			if !ok {
+				panicErr = &PanicError{Value: rec}
+				if !config.DisableStack {
+					panicErr.Stack = debug.Stack()
+				}
+			}
+			brokenPipe := isBrokenPipe(rec)
+			logPanic(writer, c, panicErr, brokenPipe)
+
+			if brokenPipe || recorder.Written() || c.written {
+				// nothing can be written to client
+				c.Abort()
+				return
+			}
+			handle(c, panicErr)
+			c.Abort()
+		}()
+		c.Next()
+	}
+}
+
+// isBrokenPipe reports whether the panic is caused by broken connection
+func isBrokenPipe(rec interface{}) bool {
+	err, ok := rec.(error)
+	if !ok {
+		return false
+	}
+	if errors.Is(err, syscall.EPIPE) || errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+	message := strings.ToLower(err.Error())
+	return strings.Contains(message, "broken pipe") || strings.Contains(message, "connection reset by peer")
+}
+
+func logPanic(writer io.Writer, c *Context, err *PanicError, brokenPipe bool) {
+	var builder strings.Builder
+	_, _ = fmt.Fprintf(&builder, "%s %s | panic recovered | %s %s\n%v\n",
+		_regia, time.Now().Format("2006/01/02 - 15:04:05"), c.Request.Method, c.Request.URL.Path, err.Value)
+	// the stack of broken pipe is meaningless
+	if !brokenPipe && len(err.Stack) > 0 {
+		builder.Write(err.Stack)
+	}
+	_, _ = io.WriteString(writer, builder.String())
+}
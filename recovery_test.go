@@ -0,0 +1,58 @@
+package regia
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestRecovery(t *testing.T) {
+	var logs bytes.Buffer
+	engine := New()
+	engine.AddInterceptors(RecoveryWithConfig(RecoveryConfig{Writer: &logs}))
+	engine.GET("/panic", func(c *Context) { panic("boom") })
+	engine.GET("/written", func(c *Context) {
+		_ = c.String("partial")
+		panic("boom")
+	})
+	engine.GET("/pipe", func(c *Context) {
+		panic(&net.OpError{Op: "write", Err: os.NewSyscallError("write", syscall.EPIPE)})
+	})
+	engine.GET("/abort", func(c *Context) { panic(http.ErrAbortHandler) })
+	_ = engine.init()
+
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	if recorder.Code != http.StatusInternalServerError || strings.Contains(recorder.Body.String(), "boom") {
+		t.Errorf("got %d %q", recorder.Code, recorder.Body.String())
+	}
+	if !strings.Contains(logs.String(), "GET /panic\nboom\n") || !strings.Contains(logs.String(), "goroutine") {
+		t.Errorf("unexpected log %q", logs.String())
+	}
+
+	recorder = httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/written", nil))
+	if recorder.Code != http.StatusOK || recorder.Body.String() != "partial" {
+		t.Errorf("got %d %q", recorder.Code, recorder.Body.String())
+	}
+
+	logs.Reset()
+	recorder = httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/pipe", nil))
+	if recorder.Body.Len() != 0 || strings.Contains(logs.String(), "goroutine") {
+		t.Errorf("broken pipe should not be replied, got %q, log %q", recorder.Body.String(), logs.String())
+	}
+
+	defer func() {
+		if rec := recover(); !errors.Is(rec.(error), http.ErrAbortHandler) {
+			t.Errorf("expected http.ErrAbortHandler, got %v", rec)
+		}
+	}()
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/abort", nil))
+}
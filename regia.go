@@ -26,6 +26,10 @@ type Engine struct {
 	// NotFoundHandle replies to the request with an HTTP 404 not found error.
 	NotFoundHandle func(context *Context)
 
+	// ErrorHandle replies to the request with given error.
+	// It is called by Context.AbortWithError
+	ErrorHandle func(context *Context, err error)
+
 	// All requests will be intercepted by interceptors
 	// whatever route matched or not
 	interceptors handleFuncNodeGroup
@@ -37,6 +41,12 @@ type Engine struct {
 	// MultipartMemory defined max request body size
 	MultipartMemory int64
 
+	// SecureJSONPrefix will be written before the body of Context.SecureJSON
+	SecureJSONPrefix string
+
+	// cookieCodec is set by Engine.SetCookieKeys
+	cookieCodec *cookieCodec
+
 	// Context pool
 	pool sync.Pool
 
@@ -54,7 +64,7 @@ func (e *Engine) dispatchContext() *Context {
 // All interceptors will be called before any handler
 // Such as authorization, rate limiter, etc
 func (e *Engine) AddInterceptors(interceptors ...HandleFunc) {
-	groups := make(handleFuncNodeGroup, len(interceptors))
+	groups := make(handleFuncNodeGroup, 0, len(interceptors))
 	for _, interceptor := range interceptors {
 		groups = append(groups, &handleFuncNode{HandleFunc: interceptor, BluePrint: e.BluePrint})
 	}
@@ -73,7 +83,13 @@ func (e *Engine) init() error {
 	for method, nodes := range e.methodsTree {
 		for _, node := range nodes {
 			hg := handleFuncNodeGroup{}
-			groups := append(e.middleware, node.group...)
+			group := node.group
+			// the routes of engine got the middlewares used before registered, do not run them twice
+			if node.blueprint == e.BluePrint {
+				group = group[node.middleware:]
+			}
+			groups := make(HandleFuncGroup, 0, len(e.middleware)+len(group))
+			groups = append(append(groups, e.middleware...), group...)
 			for _, group := range groups {
 				ns := handleFuncNode{HandleFunc: group, BluePrint: node.blueprint}
 				hg = append(hg, &ns)
@@ -104,12 +120,8 @@ func (e *Engine) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
 	// try to find all handlers
 	context.matched = e.Router.Match(context)
 
-	// if matched, then call the handler
-	if context.matched {
-		if len(e.interceptors) != 0 {
-			context.group = append(e.interceptors, context.group...)
-		}
-	} else {
+	// if not matched, then call the not found handler
+	if !context.matched {
 		// route not found
 		// add not found handler
 		// in case of not found handler is not set
@@ -118,6 +130,13 @@ func (e *Engine) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
 		context.status = http.StatusNotFound
 		context.group = handleFuncNodeGroup{&handleFuncNode{HandleFunc: e.NotFoundHandle, BluePrint: e.BluePrint}}
 	}
+	// interceptors run whatever route matched or not
+	if len(e.interceptors) != 0 {
+		// do not append to e.interceptors which is shared by all requests
+		group := make(handleFuncNodeGroup, 0, len(e.interceptors)+len(context.group))
+		group = append(group, e.interceptors...)
+		context.group = append(group, context.group...)
+	}
 
 	// start to call all handlers
 	context.start()
@@ -165,7 +184,10 @@ func New() *Engine {
 		Router:          HttpRouter{},
 		BluePrint:       DefaultBluePrint(),
 		NotFoundHandle:  HandleNotFound,
+		ErrorHandle:     HandleError,
 		MultipartMemory: defaultMultipartMemory,
+		// prevent json hijacking
+		SecureJSONPrefix: defaultSecureJSONPrefix,
 	}
 	engine.pool = sync.Pool{New: func() interface{} { return engine.dispatchContext() }}
 	return engine
@@ -175,6 +197,7 @@ func New() *Engine {
 func Default() *Engine {
 	engine := New()
 	engine.AddStarter(&BannerStarter{Banner: Banner}, &UrlInfoStarter{})
+	engine.AddInterceptors(Logger(LoggerConfig{}), Recovery())
 	return engine
 }
 
@@ -0,0 +1,56 @@
+package regia
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestInterceptors(t *testing.T) {
+	engine := New()
+	var calls int
+	engine.AddInterceptors(func(c *Context) {
+		calls++
+		c.SetHeader("X-Intercepted", "true")
+	})
+	engine.GET("/", func(c *Context) { _ = c.String("ok") })
+	_ = engine.init()
+
+	for _, test := range []struct {
+		path string
+		code int
+	}{
+		{"/", http.StatusOK},
+		{"/missing", http.StatusNotFound},
+	} {
+		calls = 0
+		recorder := httptest.NewRecorder()
+		engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, test.path, nil))
+		if recorder.Code != test.code || recorder.Header().Get("X-Intercepted") != "true" || calls != 1 {
+			t.Errorf("%s: got %d, intercepted %q, %d calls", test.path, recorder.Code,
+				recorder.Header().Get("X-Intercepted"), calls)
+		}
+	}
+}
+
+func TestEngineMiddleware(t *testing.T) {
+	engine := New()
+	var calls int
+	counter := func(c *Context) { calls++ }
+	engine.Use(counter)
+	engine.GET("/before", func(c *Context) {})
+	engine.Use(counter)
+	engine.GET("/after", func(c *Context) {})
+	blueprint := NewBluePrint()
+	blueprint.GET("/", func(c *Context) {})
+	engine.Include("/blueprint", blueprint)
+	_ = engine.init()
+
+	for _, path := range []string{"/before", "/after", "/blueprint/"} {
+		calls = 0
+		engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+		if calls != 2 {
+			t.Errorf("%s: engine middlewares should run once each, got %d calls", path, calls)
+		}
+	}
+}
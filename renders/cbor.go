@@ -0,0 +1,24 @@
+// Copyright 2022 eatmoreapple.  All rights reserved.
+// Use of this source code is governed by a GPL style
+// license that can be found in the LICENSE file.
+
+package renders
+
+import (
+	"net/http"
+
+	"github.com/eatmoreapple/regia/serializers"
+)
+
+type CborRender struct {
+	Serializer serializers.Serializer
+}
+
+func (c CborRender) WriterHeader(writer http.ResponseWriter, code int) {
+	writeContentType(writer, "application/cbor")
+	writeHeader(writer, code)
+}
+
+func (c CborRender) Render(writer http.ResponseWriter, data interface{}) error {
+	return c.Serializer.Encode(writer, data)
+}
@@ -0,0 +1,152 @@
+// Copyright 2022 eatmoreapple.  All rights reserved.
+// Use of this source code is governed by a GPL style
+// license that can be found in the LICENSE file.
+
+package renders
+
+import (
+	"encoding"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/eatmoreapple/regia/internal"
+)
+
+// utf8BOM let Excel open utf-8 csv file correctly
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// CSV renders slice, array or channel of structs as csv
+// Column order and headers come from `csv` struct tags
+// Rows will be written one by one, channel source will be flushed after each row
+type CSV struct {
+	// Filename set Content-Disposition as attachment if not empty
+	Filename string
+	// Comma is the field delimiter, default is ','
+	Comma rune
+	// BOM writes utf-8 byte order mark before data
+	BOM bool
+}
+
+func (c CSV) WriterHeader(writer http.ResponseWriter, code int) {
+	if len(c.Filename) > 0 {
+		writer.Header().Set("Content-Disposition", "attachment; filename=\""+c.Filename+"\"")
+	}
+	writeContentType(writer, "text/csv; charset=utf-8")
+	writeHeader(writer, code)
+}
+
+func (c CSV) Render(writer http.ResponseWriter, data interface{}) error {
+	value := reflect.Indirect(reflect.ValueOf(data))
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Chan:
+	default:
+		return errors.New("slice, array or chan of struct required")
+	}
+	elemType := value.Type().Elem()
+	if elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+	if elemType.Kind() != reflect.Struct {
+		return errors.New("slice, array or chan of struct required")
+	}
+	if c.BOM {
+		if _, err := writer.Write(utf8BOM); err != nil {
+			return err
+		}
+	}
+	csvWriter := csv.NewWriter(writer)
+	if c.Comma != 0 {
+		csvWriter.Comma = c.Comma
+	}
+	columns := internal.CSVColumns(elemType)
+	record := make([]string, len(columns))
+	for i, column := range columns {
+		record[i] = column.Name
+	}
+	if err := csvWriter.Write(record); err != nil {
+		return err
+	}
+	writeRow := func(row reflect.Value) error {
+		row = reflect.Indirect(row)
+		for i, column := range columns {
+			if !row.IsValid() {
+				record[i] = ""
+				continue
+			}
+			record[i] = formatCSVValue(row.FieldByIndex(column.Index))
+		}
+		return csvWriter.Write(record)
+	}
+	if value.Kind() == reflect.Chan {
+		flusher, _ := writer.(http.Flusher)
+		for {
+			row, ok := value.Recv()
+			if !ok {
+				break
+			}
+			if err := writeRow(row); err != nil {
+				return err
+			}
+			csvWriter.Flush()
+			if err := csvWriter.Error(); err != nil {
+				return err
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+	} else {
+		for i := 0; i < value.Len(); i++ {
+			if err := writeRow(value.Index(i)); err != nil {
+				return err
+			}
+		}
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+func formatCSVValue(value reflect.Value) string {
+	if value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return ""
+		}
+		value = value.Elem()
+	}
+	switch v := value.Interface().(type) {
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.Format(time.RFC3339)
+	case encoding.TextMarshaler:
+		text, err := v.MarshalText()
+		if err != nil {
+			return ""
+		}
+		return string(text)
+	case fmt.Stringer:
+		return v.String()
+	}
+	switch value.Kind() {
+	case reflect.String:
+		return value.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(value.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(value.Uint(), 10)
+	case reflect.Float32:
+		return strconv.FormatFloat(value.Float(), 'f', -1, 32)
+	case reflect.Float64:
+		return strconv.FormatFloat(value.Float(), 'f', -1, 64)
+	case reflect.Bool:
+		return strconv.FormatBool(value.Bool())
+	default:
+		return fmt.Sprint(value.Interface())
+	}
+}
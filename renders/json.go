@@ -5,13 +5,20 @@
 package renders
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"regexp"
+	"unicode/utf16"
+	"unicode/utf8"
 
-	"github.com/eatmoreapple/regia/internal"
+	"github.com/eatmoreapple/regia/serializers"
 )
 
 type JsonRender struct {
-	Serializer internal.Serializer
+	Serializer serializers.Serializer
 }
 
 func (j JsonRender) WriterHeader(writer http.ResponseWriter, code int) {
@@ -22,3 +29,134 @@ func (j JsonRender) WriterHeader(writer http.ResponseWriter, code int) {
 func (j JsonRender) Render(writer http.ResponseWriter, data interface{}) error {
 	return j.Serializer.Encode(writer, data)
 }
+
+// IndentedJsonRender writes pretty json
+// Output of Serializer will be indented, so the customized Serializer still works
+type IndentedJsonRender struct {
+	Serializer serializers.Serializer
+	Prefix     string
+	Indent     string
+}
+
+func (i IndentedJsonRender) WriterHeader(writer http.ResponseWriter, code int) {
+	writeContentType(writer, "application/json; charset=utf-8")
+	writeHeader(writer, code)
+}
+
+func (i IndentedJsonRender) Render(writer http.ResponseWriter, data interface{}) error {
+	var buf bytes.Buffer
+	if err := i.Serializer.Encode(&buf, data); err != nil {
+		return err
+	}
+	var out bytes.Buffer
+	if err := json.Indent(&out, bytes.TrimSpace(buf.Bytes()), i.Prefix, i.Indent); err != nil {
+		return err
+	}
+	out.WriteByte('\n')
+	_, err := out.WriteTo(writer)
+	return err
+}
+
+// AsciiJsonRender writes json with non-ASCII characters escaped to \uXXXX
+type AsciiJsonRender struct {
+	Serializer serializers.Serializer
+}
+
+func (a AsciiJsonRender) WriterHeader(writer http.ResponseWriter, code int) {
+	writeContentType(writer, "application/json")
+	writeHeader(writer, code)
+}
+
+func (a AsciiJsonRender) Render(writer http.ResponseWriter, data interface{}) error {
+	var buf bytes.Buffer
+	if err := a.Serializer.Encode(&buf, data); err != nil {
+		return err
+	}
+	_, err := writer.Write(escapeNonASCII(buf.Bytes()))
+	return err
+}
+
+// SecureJsonRender writes json with Prefix to defeat json hijacking
+// The client must strip the Prefix before parsing the response
+type SecureJsonRender struct {
+	Serializer serializers.Serializer
+	Prefix     string
+}
+
+func (s SecureJsonRender) WriterHeader(writer http.ResponseWriter, code int) {
+	writeContentType(writer, "application/json; charset=utf-8")
+	writeHeader(writer, code)
+}
+
+func (s SecureJsonRender) Render(writer http.ResponseWriter, data interface{}) error {
+	var buf bytes.Buffer
+	buf.WriteString(s.Prefix)
+	if err := s.Serializer.Encode(&buf, data); err != nil {
+		return err
+	}
+	_, err := buf.WriteTo(writer)
+	return err
+}
+
+// ErrInvalidJSONPCallback returned when the callback name of jsonp is not a valid javascript identifier
+var ErrInvalidJSONPCallback = errors.New("invalid jsonp callback name")
+
+var jsonpCallbackRegexp = regexp.MustCompile(`^[a-zA-Z_$][a-zA-Z0-9_$]*(\.[a-zA-Z_$][a-zA-Z0-9_$]*)*$`)
+
+// ValidJSONPCallback reports whether name is safe to be used as jsonp callback
+// Only javascript identifiers joined with dots are allowed
+func ValidJSONPCallback(name string) bool {
+	return len(name) <= 128 && jsonpCallbackRegexp.MatchString(name)
+}
+
+// JsonpRender writes json wrapped with Callback as javascript
+type JsonpRender struct {
+	Serializer serializers.Serializer
+	Callback   string
+}
+
+func (j JsonpRender) WriterHeader(writer http.ResponseWriter, code int) {
+	writeContentType(writer, "application/javascript; charset=utf-8")
+	writer.Header().Set("X-Content-Type-Options", "nosniff")
+	writeHeader(writer, code)
+}
+
+func (j JsonpRender) Render(writer http.ResponseWriter, data interface{}) error {
+	if !ValidJSONPCallback(j.Callback) {
+		return ErrInvalidJSONPCallback
+	}
+	var buf bytes.Buffer
+	if err := j.Serializer.Encode(&buf, data); err != nil {
+		return err
+	}
+	body := bytes.TrimSpace(buf.Bytes())
+	// U+2028 and U+2029 are valid in json but not in javascript string
+	body = bytes.ReplaceAll(body, []byte("\u2028"), []byte(`\u2028`))
+	body = bytes.ReplaceAll(body, []byte("\u2029"), []byte(`\u2029`))
+
+	var out bytes.Buffer
+	// the leading comment prevents the Rosetta Flash attack
+	out.WriteString("/**/ typeof " + j.Callback + " === 'function' && " + j.Callback + "(")
+	out.Write(body)
+	out.WriteString(");")
+	_, err := out.WriteTo(writer)
+	return err
+}
+
+// escapeNonASCII escapes all non-ASCII characters to \uXXXX
+func escapeNonASCII(data []byte) []byte {
+	var buf bytes.Buffer
+	buf.Grow(len(data))
+	for _, r := range string(data) {
+		switch {
+		case r < utf8.RuneSelf:
+			buf.WriteRune(r)
+		case r > 0xFFFF:
+			r1, r2 := utf16.EncodeRune(r)
+			fmt.Fprintf(&buf, "\\u%04x\\u%04x", r1, r2)
+		default:
+			fmt.Fprintf(&buf, "\\u%04x", r)
+		}
+	}
+	return buf.Bytes()
+}
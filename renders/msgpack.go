@@ -0,0 +1,24 @@
+// Copyright 2022 eatmoreapple.  All rights reserved.
+// Use of this source code is governed by a GPL style
+// license that can be found in the LICENSE file.
+
+package renders
+
+import (
+	"net/http"
+
+	"github.com/eatmoreapple/regia/serializers"
+)
+
+type MsgPackRender struct {
+	Serializer serializers.Serializer
+}
+
+func (m MsgPackRender) WriterHeader(writer http.ResponseWriter, code int) {
+	writeContentType(writer, "application/msgpack")
+	writeHeader(writer, code)
+}
+
+func (m MsgPackRender) Render(writer http.ResponseWriter, data interface{}) error {
+	return m.Serializer.Encode(writer, data)
+}
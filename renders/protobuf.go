@@ -0,0 +1,24 @@
+// Copyright 2022 eatmoreapple.  All rights reserved.
+// Use of this source code is governed by a GPL style
+// license that can be found in the LICENSE file.
+
+package renders
+
+import (
+	"net/http"
+
+	"github.com/eatmoreapple/regia/serializers"
+)
+
+type ProtobufRender struct {
+	Serializer serializers.Serializer
+}
+
+func (p ProtobufRender) WriterHeader(writer http.ResponseWriter, code int) {
+	writeContentType(writer, "application/x-protobuf")
+	writeHeader(writer, code)
+}
+
+func (p ProtobufRender) Render(writer http.ResponseWriter, data interface{}) error {
+	return p.Serializer.Encode(writer, data)
+}
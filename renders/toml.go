@@ -0,0 +1,24 @@
+// Copyright 2022 eatmoreapple.  All rights reserved.
+// Use of this source code is governed by a GPL style
+// license that can be found in the LICENSE file.
+
+package renders
+
+import (
+	"net/http"
+
+	"github.com/eatmoreapple/regia/serializers"
+)
+
+type TomlRender struct {
+	Serializer serializers.Serializer
+}
+
+func (t TomlRender) WriterHeader(writer http.ResponseWriter, code int) {
+	writeContentType(writer, "application/toml; charset=utf-8")
+	writeHeader(writer, code)
+}
+
+func (t TomlRender) Render(writer http.ResponseWriter, data interface{}) error {
+	return t.Serializer.Encode(writer, data)
+}
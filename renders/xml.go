@@ -7,11 +7,11 @@ package renders
 import (
 	"net/http"
 
-	"github.com/eatmoreapple/regia/internal"
+	"github.com/eatmoreapple/regia/serializers"
 )
 
 type XmlRender struct {
-	Serializer internal.Serializer
+	Serializer serializers.Serializer
 }
 
 func (x XmlRender) WriterHeader(writer http.ResponseWriter, code int) {
@@ -0,0 +1,24 @@
+// Copyright 2022 eatmoreapple.  All rights reserved.
+// Use of this source code is governed by a GPL style
+// license that can be found in the LICENSE file.
+
+package renders
+
+import (
+	"net/http"
+
+	"github.com/eatmoreapple/regia/serializers"
+)
+
+type YamlRender struct {
+	Serializer serializers.Serializer
+}
+
+func (y YamlRender) WriterHeader(writer http.ResponseWriter, code int) {
+	writeContentType(writer, "application/x-yaml; charset=utf-8")
+	writeHeader(writer, code)
+}
+
+func (y YamlRender) Render(writer http.ResponseWriter, data interface{}) error {
+	return y.Serializer.Encode(writer, data)
+}
@@ -0,0 +1,89 @@
+// Copyright 2022 eatmoreapple.  All rights reserved.
+// Use of this source code is governed by a GPL style
+// license that can be found in the LICENSE file.
+
+package regia
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+)
+
+// responseWriter records the status and size of response
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+	size   int
+}
+
+// WriteHeader records the first status code, the later ones will be ignored
+func (w *responseWriter) WriteHeader(code int) {
+	if w.status != 0 {
+		return
+	}
+	w.status = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.size += n
+	return n, err
+}
+
+// Status returns the status code written, 0 if nothing written
+func (w *responseWriter) Status() int {
+	return w.status
+}
+
+// Size returns the size of body written
+func (w *responseWriter) Size() int {
+	return w.size
+}
+
+// Written reports whether the header has been written
+func (w *responseWriter) Written() bool {
+	return w.status != 0
+}
+
+// Flush implements http.Flusher
+func (w *responseWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		flusher.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("http.Hijacker not implemented")
+	}
+	if w.status == 0 {
+		w.status = http.StatusSwitchingProtocols
+	}
+	return hijacker.Hijack()
+}
+
+// Unwrap returns the original http.ResponseWriter for http.ResponseController
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// recordResponse wraps Context.ResponseWriter with responseWriter if not wrapped
+func (c *Context) recordResponse() *responseWriter {
+	if w, ok := c.ResponseWriter.(*responseWriter); ok {
+		return w
+	}
+	w := &responseWriter{ResponseWriter: c.ResponseWriter}
+	c.ResponseWriter = w
+	return w
+}
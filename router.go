@@ -4,6 +4,8 @@
 
 package regia
 
+import "sort"
+
 // HttpRouter implement Router
 type HttpRouter map[string]*routerNode
 
@@ -25,11 +27,23 @@ func (r HttpRouter) Insert(method, path string, handle handleFuncNodeGroup) {
 func (r HttpRouter) Match(ctx *Context) bool {
 	method := ctx.Request.Method
 	if root := r[method]; root != nil {
-		group, params, _ := root.getValue(ctx.Request.URL.Path)
-		ctx.fullPath = root.fullPath
+		group, params, route, _ := root.getValue(ctx.Request.URL.Path)
+		ctx.fullPath = route
 		ctx.params = params
 		ctx.group = group
 		return group != nil
 	}
 	return false
 }
+
+// AllowedMethods returns the sorted methods registered for path
+func (r HttpRouter) AllowedMethods(path string) []string {
+	var methods []string
+	for method, root := range r {
+		if handle, _, _, _ := root.getValue(path); handle != nil {
+			methods = append(methods, method)
+		}
+	}
+	sort.Strings(methods)
+	return methods
+}
@@ -0,0 +1,21 @@
+// Copyright 2022 eatmoreapple.  All rights reserved.
+// Use of this source code is governed by a GPL style
+// license that can be found in the LICENSE file.
+
+package serializers
+
+import (
+	"io"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+type CborSerializer struct{}
+
+func (c CborSerializer) Encode(writer io.Writer, v interface{}) error {
+	return cbor.NewEncoder(writer).Encode(v)
+}
+
+func (c CborSerializer) Decode(reader io.Reader, v interface{}) error {
+	return cbor.NewDecoder(reader).Decode(v)
+}
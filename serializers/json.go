@@ -0,0 +1,33 @@
+// Copyright 2022 eatmoreapple.  All rights reserved.
+// Use of this source code is governed by a GPL style
+// license that can be found in the LICENSE file.
+
+package serializers
+
+import (
+	"encoding/json"
+	"io"
+)
+
+type JsonSerializer struct {
+	// DisallowUnknownFields causes Decode to return an error when the destination
+	// is a struct and the input contains object keys which do not match any field
+	DisallowUnknownFields bool
+	// UseNumber causes Decode to unmarshal a number into an interface{} as a json.Number
+	UseNumber bool
+}
+
+func (j JsonSerializer) Encode(writer io.Writer, v interface{}) error {
+	return json.NewEncoder(writer).Encode(v)
+}
+
+func (j JsonSerializer) Decode(reader io.Reader, v interface{}) error {
+	decoder := json.NewDecoder(reader)
+	if j.DisallowUnknownFields {
+		decoder.DisallowUnknownFields()
+	}
+	if j.UseNumber {
+		decoder.UseNumber()
+	}
+	return decoder.Decode(v)
+}
@@ -0,0 +1,21 @@
+// Copyright 2022 eatmoreapple.  All rights reserved.
+// Use of this source code is governed by a GPL style
+// license that can be found in the LICENSE file.
+
+package serializers
+
+import (
+	"io"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+type MsgPackSerializer struct{}
+
+func (m MsgPackSerializer) Encode(writer io.Writer, v interface{}) error {
+	return msgpack.NewEncoder(writer).Encode(v)
+}
+
+func (m MsgPackSerializer) Decode(reader io.Reader, v interface{}) error {
+	return msgpack.NewDecoder(reader).Decode(v)
+}
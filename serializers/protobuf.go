@@ -0,0 +1,42 @@
+// Copyright 2022 eatmoreapple.  All rights reserved.
+// Use of this source code is governed by a GPL style
+// license that can be found in the LICENSE file.
+
+package serializers
+
+import (
+	"errors"
+	"io"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// ErrNotProtoMessage returned when the given value does not implement proto.Message
+var ErrNotProtoMessage = errors.New("proto.Message type required")
+
+type ProtobufSerializer struct{}
+
+func (p ProtobufSerializer) Encode(writer io.Writer, v interface{}) error {
+	message, ok := v.(proto.Message)
+	if !ok {
+		return ErrNotProtoMessage
+	}
+	data, err := proto.Marshal(message)
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(data)
+	return err
+}
+
+func (p ProtobufSerializer) Decode(reader io.Reader, v interface{}) error {
+	message, ok := v.(proto.Message)
+	if !ok {
+		return ErrNotProtoMessage
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	return proto.Unmarshal(data, message)
+}
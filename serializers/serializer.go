@@ -0,0 +1,15 @@
+// Copyright 2022 eatmoreapple.  All rights reserved.
+// Use of this source code is governed by a GPL style
+// license that can be found in the LICENSE file.
+
+package serializers
+
+import "io"
+
+// Serializer is used to encode response data and decode request body.
+// Implement it to replace the default one of BluePrint,
+// such as jsoniter for json.
+type Serializer interface {
+	Encode(writer io.Writer, v interface{}) error
+	Decode(reader io.Reader, v interface{}) error
+}
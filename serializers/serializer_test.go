@@ -0,0 +1,38 @@
+package serializers
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+type item struct {
+	Name  string
+	Count int
+	Tags  []string
+}
+
+func TestSerializers(t *testing.T) {
+	serializers := map[string]Serializer{
+		"json":    JsonSerializer{},
+		"xml":     XmlSerializer{},
+		"yaml":    YamlSerializer{},
+		"toml":    TomlSerializer{},
+		"msgpack": MsgPackSerializer{},
+		"cbor":    CborSerializer{},
+	}
+	want := item{Name: "regia", Count: 3, Tags: []string{"a", "b"}}
+	for name, serializer := range serializers {
+		var buf bytes.Buffer
+		if err := serializer.Encode(&buf, want); err != nil {
+			t.Fatalf("%s: encode: %v", name, err)
+		}
+		var got item
+		if err := serializer.Decode(&buf, &got); err != nil {
+			t.Fatalf("%s: decode: %v", name, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: expected %v, got %v", name, want, got)
+		}
+	}
+}
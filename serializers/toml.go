@@ -0,0 +1,22 @@
+// Copyright 2022 eatmoreapple.  All rights reserved.
+// Use of this source code is governed by a GPL style
+// license that can be found in the LICENSE file.
+
+package serializers
+
+import (
+	"io"
+
+	"github.com/BurntSushi/toml"
+)
+
+type TomlSerializer struct{}
+
+func (t TomlSerializer) Encode(writer io.Writer, v interface{}) error {
+	return toml.NewEncoder(writer).Encode(v)
+}
+
+func (t TomlSerializer) Decode(reader io.Reader, v interface{}) error {
+	_, err := toml.NewDecoder(reader).Decode(v)
+	return err
+}
@@ -0,0 +1,20 @@
+// Copyright 2022 eatmoreapple.  All rights reserved.
+// Use of this source code is governed by a GPL style
+// license that can be found in the LICENSE file.
+
+package serializers
+
+import (
+	"encoding/xml"
+	"io"
+)
+
+type XmlSerializer struct{}
+
+func (x XmlSerializer) Encode(writer io.Writer, v interface{}) error {
+	return xml.NewEncoder(writer).Encode(v)
+}
+
+func (x XmlSerializer) Decode(reader io.Reader, v interface{}) error {
+	return xml.NewDecoder(reader).Decode(v)
+}
@@ -0,0 +1,31 @@
+// Copyright 2022 eatmoreapple.  All rights reserved.
+// Use of this source code is governed by a GPL style
+// license that can be found in the LICENSE file.
+
+package serializers
+
+import (
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+type YamlSerializer struct {
+	// KnownFields causes Decode to return an error when the destination
+	// is a struct and the input contains keys which do not match any field
+	KnownFields bool
+}
+
+func (y YamlSerializer) Encode(writer io.Writer, v interface{}) error {
+	encoder := yaml.NewEncoder(writer)
+	if err := encoder.Encode(v); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+func (y YamlSerializer) Decode(reader io.Reader, v interface{}) error {
+	decoder := yaml.NewDecoder(reader)
+	decoder.KnownFields(y.KnownFields)
+	return decoder.Decode(v)
+}
@@ -0,0 +1,505 @@
+// Copyright 2022 eatmoreapple.  All rights reserved.
+// Use of this source code is governed by a GPL style
+// license that can be found in the LICENSE file.
+
+package regia
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	sessionKey             = "regia.session"
+	sessionFlashKey        = "_flashes"
+	sessionFlashesFuncName = "flashes"
+	sessionIDLength        = 32
+	sessionSweepEvery      = 1024
+	defaultSessionCookie   = "session"
+	defaultSessionIdle     = 30 * time.Minute
+	defaultSessionAbsolute = 24 * time.Hour
+)
+
+func init() {
+	gob.Register([]Flash{})
+	registerRequestTemplateFunc(sessionFlashesFuncName)
+}
+
+// Flash is a message shown once, such as the result of last form submission
+type Flash struct {
+	Kind    string
+	Message string
+}
+
+// Session is the state of a client across requests
+// Values are encoded by encoding/gob, so register the custom types with gob.Register
+type Session struct {
+	id       string
+	values   map[string]interface{}
+	created  time.Time
+	accessed time.Time
+
+	// flashes are added by the previous request
+	flashes []Flash
+
+	isNew     bool
+	modified  bool
+	destroyed bool
+	// oldID is deleted from store after RenewID
+	oldID string
+}
+
+// sessionRecord is the encoded form of Session
+type sessionRecord struct {
+	ID       string
+	Values   map[string]interface{}
+	Created  time.Time
+	Accessed time.Time
+}
+
+// NewSession constructor for Session with a random id
+func NewSession() (*Session, error) {
+	id, err := newSessionID()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	return &Session{id: id, values: make(map[string]interface{}), created: now, accessed: now, isNew: true}, nil
+}
+
+func newSessionID() (string, error) {
+	id := make([]byte, sessionIDLength)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(id), nil
+}
+
+// ID returns the id of session
+func (s *Session) ID() string {
+	return s.id
+}
+
+// CreatedAt returns when the session is created, the absolute timeout starts from it
+func (s *Session) CreatedAt() time.Time {
+	return s.created
+}
+
+// AccessedAt returns when the session is accessed last time, the idle timeout starts from it
+func (s *Session) AccessedAt() time.Time {
+	return s.accessed
+}
+
+// IsNew reports whether the session is created by current request
+func (s *Session) IsNew() bool {
+	return s.isNew
+}
+
+// Get returns the value of key
+func (s *Session) Get(key string) (interface{}, bool) {
+	value, exist := s.values[key]
+	return value, exist
+}
+
+// Set sets the value of key
+func (s *Session) Set(key string, value interface{}) {
+	s.values[key] = value
+	s.modified = true
+}
+
+// Delete deletes the value of key
+func (s *Session) Delete(key string) {
+	if _, exist := s.values[key]; exist {
+		delete(s.values, key)
+		s.modified = true
+	}
+}
+
+// Clear deletes all values
+func (s *Session) Clear() {
+	if len(s.values) > 0 {
+		s.values = make(map[string]interface{})
+		s.modified = true
+	}
+}
+
+// AddFlash adds a flash message for the next request, such as redirect after form submission
+func (s *Session) AddFlash(kind, message string) {
+	flashes, _ := s.values[sessionFlashKey].([]Flash)
+	s.Set(sessionFlashKey, append(flashes, Flash{Kind: kind, Message: message}))
+}
+
+// Flashes returns the flash messages added by the previous request
+// They are discarded after current request whether read or not
+// They can be read by templates of TemplateLoader too
+//
+//	{{ range flashes }}<p class="{{ .Kind }}">{{ .Message }}</p>{{ end }}
+func (s *Session) Flashes() []Flash {
+	return s.flashes
+}
+
+// takeFlashes moves the flash messages of previous request out of values
+func (s *Session) takeFlashes() {
+	s.flashes, _ = s.values[sessionFlashKey].([]Flash)
+	s.Delete(sessionFlashKey)
+}
+
+// RenewID changes the id of session and keeps its values
+// Call it after the privilege changes, such as login, against session fixation
+func (s *Session) RenewID() error {
+	id, err := newSessionID()
+	if err != nil {
+		return err
+	}
+	if len(s.oldID) == 0 && !s.isNew {
+		s.oldID = s.id
+	}
+	s.id = id
+	s.modified = true
+	return nil
+}
+
+// Destroy deletes the session from store and client, such as logout
+func (s *Session) Destroy() {
+	s.values = make(map[string]interface{})
+	s.destroyed = true
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler
+func (s *Session) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	record := sessionRecord{ID: s.id, Values: s.values, Created: s.created, Accessed: s.accessed}
+	if err := gob.NewEncoder(&buf).Encode(record); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler
+func (s *Session) UnmarshalBinary(data []byte) error {
+	var record sessionRecord
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&record); err != nil {
+		return err
+	}
+	if record.Values == nil {
+		record.Values = make(map[string]interface{})
+	}
+	*s = Session{id: record.ID, values: record.Values, created: record.Created, accessed: record.Accessed}
+	return nil
+}
+
+// encodeSessionEntry encodes the session prefixed with its expire time
+func encodeSessionEntry(session *Session, expire time.Time) ([]byte, error) {
+	data, err := session.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	entry := make([]byte, 8, 8+len(data))
+	binary.BigEndian.PutUint64(entry, uint64(expire.UnixNano()))
+	return append(entry, data...), nil
+}
+
+// decodeSessionEntry decodes the entry of encodeSessionEntry, nil returned if expired
+func decodeSessionEntry(entry []byte, now time.Time) (*Session, error) {
+	if len(entry) < 8 {
+		return nil, errors.New("invalid session entry")
+	}
+	if expire := time.Unix(0, int64(binary.BigEndian.Uint64(entry))); !now.Before(expire) {
+		return nil, nil
+	}
+	session := new(Session)
+	if err := session.UnmarshalBinary(entry[8:]); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// SessionStore saves sessions
+// Implement it with external backend, such as redis, to share sessions between instances
+type SessionStore interface {
+	// Load returns the session of cookie value, nil if not found or expired
+	Load(value string) (*Session, error)
+	// Save saves the session until expire and returns the cookie value
+	Save(session *Session, expire time.Time) (string, error)
+	// Delete deletes the session of id
+	Delete(id string) error
+}
+
+// SessionConfig is the config of Sessions
+type SessionConfig struct {
+	// Store default NewMemorySessionStore
+	Store SessionStore
+	// CookieName default session
+	CookieName   string
+	CookiePath   string
+	CookieDomain string
+	// Secure sends the cookie over https only
+	Secure bool
+	// DisableHTTPOnly exposes the cookie to javascript
+	DisableHTTPOnly bool
+	// SameSite default http.SameSiteLaxMode
+	SameSite http.SameSite
+	// IdleTimeout expires the session not accessed for it, default 30 minutes
+	IdleTimeout time.Duration
+	// AbsoluteTimeout expires the session created before it regardless of access, default 24 hours
+	AbsoluteTimeout time.Duration
+}
+
+// Sessions returns a middleware which loads the session of request and saves it before response written
+// The session can be got by GetSession, it is saved only if it is modified or loaded from store
+// Modify the session before writing response, the later changes are not saved
+// If the session fails to save, the response of handlers is dropped and the error is replied
+//
+//	engine.Use(regia.Sessions(regia.SessionConfig{Store: regia.NewCookieSessionStore(key)}))
+func Sessions(config SessionConfig) HandleFunc {
+	if config.Store == nil {
+		config.Store = NewMemorySessionStore()
+	}
+	if len(config.CookieName) == 0 {
+		config.CookieName = defaultSessionCookie
+	}
+	if len(config.CookiePath) == 0 {
+		config.CookiePath = "/"
+	}
+	if config.SameSite == 0 {
+		config.SameSite = http.SameSiteLaxMode
+	}
+	if config.IdleTimeout <= 0 {
+		config.IdleTimeout = defaultSessionIdle
+	}
+	if config.AbsoluteTimeout <= 0 {
+		config.AbsoluteTimeout = defaultSessionAbsolute
+	}
+
+	return func(c *Context) {
+		now := time.Now()
+		session, err := config.loadSession(c, now)
+		if err != nil {
+			c.AbortWithError(err)
+			return
+		}
+		c.SetValue(sessionKey, session)
+		c.SetTemplateFunc(sessionFlashesFuncName, session.Flashes)
+		addVary(c.ResponseWriter.Header(), "Cookie")
+
+		// the cookie must be set before the header written
+		writer := &sessionWriter{ResponseWriter: c.ResponseWriter}
+		writer.save = func() error { return config.saveSession(writer.ResponseWriter, session, now) }
+		c.ResponseWriter = writer
+		index := c.index
+		c.Next()
+		c.ResponseWriter = writer.ResponseWriter
+		if err = writer.beforeWrite(); err != nil {
+			// nothing has been written since the session failed to save, reply the error instead,
+			// the index is restored to render it with the BluePrint of this middleware
+			c.index = index
+			c.written = false
+			c.AbortWithError(err)
+		}
+	}
+}
+
+// GetSession returns the session loaded by Sessions
+func GetSession(c *Context) *Session {
+	value, _ := c.GetValue(sessionKey)
+	session, _ := value.(*Session)
+	return session
+}
+
+func (s SessionConfig) loadSession(c *Context, now time.Time) (*Session, error) {
+	if cookie, err := c.Request.Cookie(s.CookieName); err == nil && len(cookie.Value) > 0 {
+		session, err := s.Store.Load(cookie.Value)
+		if err != nil {
+			return nil, err
+		}
+		if session != nil {
+			if now.Sub(session.accessed) < s.IdleTimeout && now.Sub(session.created) < s.AbsoluteTimeout {
+				session.takeFlashes()
+				return session, nil
+			}
+			if err = s.Store.Delete(session.id); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return NewSession()
+}
+
+func (s SessionConfig) saveSession(w http.ResponseWriter, session *Session, now time.Time) error {
+	cookie := &http.Cookie{
+		Name:     s.CookieName,
+		Path:     s.CookiePath,
+		Domain:   s.CookieDomain,
+		Secure:   s.Secure,
+		HttpOnly: !s.DisableHTTPOnly,
+		SameSite: s.SameSite,
+	}
+	if len(session.oldID) > 0 {
+		if err := s.Store.Delete(session.oldID); err != nil {
+			return err
+		}
+		session.oldID = ""
+	}
+	if session.destroyed {
+		if session.isNew {
+			return nil
+		}
+		cookie.MaxAge = -1
+		http.SetCookie(w, cookie)
+		return s.Store.Delete(session.id)
+	}
+	// an untouched new session is not worth saving
+	if session.isNew && !session.modified {
+		return nil
+	}
+	session.accessed = now
+	expire := now.Add(s.IdleTimeout)
+	if absolute := session.created.Add(s.AbsoluteTimeout); absolute.Before(expire) {
+		expire = absolute
+	}
+	value, err := s.Store.Save(session, expire)
+	if err != nil {
+		return err
+	}
+	cookie.Value = value
+	cookie.MaxAge = int(expire.Sub(now) / time.Second)
+	http.SetCookie(w, cookie)
+	return nil
+}
+
+// sessionWriter saves the session before the header written
+type sessionWriter struct {
+	http.ResponseWriter
+	save  func() error
+	saved bool
+	// err is the error of saving session, the response is dropped if it is not nil
+	err error
+}
+
+// beforeWrite saves the session once and returns the error of saving
+func (w *sessionWriter) beforeWrite() error {
+	if !w.saved {
+		w.saved = true
+		w.err = w.save()
+	}
+	return w.err
+}
+
+// WriteHeader drops the header if the session can not be saved,
+// the error is replied by Sessions after handlers
+func (w *sessionWriter) WriteHeader(code int) {
+	if w.beforeWrite() != nil {
+		return
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *sessionWriter) Write(b []byte) (int, error) {
+	if err := w.beforeWrite(); err != nil {
+		return 0, err
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher
+func (w *sessionWriter) Flush() {
+	if w.beforeWrite() != nil {
+		return
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker
+func (w *sessionWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("http.Hijacker not implemented")
+	}
+	// the session can not be saved by cookie after hijacked
+	w.saved = true
+	return hijacker.Hijack()
+}
+
+// Unwrap returns the original http.ResponseWriter for http.ResponseController
+func (w *sessionWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// MemorySessionStore is a SessionStore in memory
+// Sessions are lost after restart and not shared between instances
+type MemorySessionStore struct {
+	lock    sync.Mutex
+	entries map[string][]byte
+	saves   int
+	// now is used to get current time, replaceable in test
+	now func() time.Time
+}
+
+// NewMemorySessionStore constructor for MemorySessionStore
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{entries: make(map[string][]byte), now: time.Now}
+}
+
+// Load implements SessionStore
+func (m *MemorySessionStore) Load(value string) (*Session, error) {
+	m.lock.Lock()
+	entry, exist := m.entries[value]
+	m.lock.Unlock()
+	if !exist {
+		return nil, nil
+	}
+	return decodeSessionEntry(entry, m.now())
+}
+
+// Save implements SessionStore
+// The session is encoded, so it is not shared by requests
+func (m *MemorySessionStore) Save(session *Session, expire time.Time) (string, error) {
+	entry, err := encodeSessionEntry(session, expire)
+	if err != nil {
+		return "", err
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.saves++
+	if m.saves%sessionSweepEvery == 0 {
+		m.sweep()
+	}
+	m.entries[session.id] = entry
+	return session.id, nil
+}
+
+// Delete implements SessionStore
+func (m *MemorySessionStore) Delete(id string) error {
+	m.lock.Lock()
+	delete(m.entries, id)
+	m.lock.Unlock()
+	return nil
+}
+
+// Len returns the count of sessions stored
+func (m *MemorySessionStore) Len() int {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return len(m.entries)
+}
+
+// sweep evicts the expired sessions
+func (m *MemorySessionStore) sweep() {
+	now := uint64(m.now().UnixNano())
+	for id, entry := range m.entries {
+		if binary.BigEndian.Uint64(entry) <= now {
+			delete(m.entries, id)
+		}
+	}
+}
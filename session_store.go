@@ -0,0 +1,182 @@
+// Copyright 2022 eatmoreapple.  All rights reserved.
+// Use of this source code is governed by a GPL style
+// license that can be found in the LICENSE file.
+
+package regia
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/eatmoreapple/regia/internal"
+)
+
+const (
+	cookieSessionName    = "regia.session"
+	maxCookieSessionSize = 4000
+	sessionFileExt       = ".session"
+)
+
+// ErrSessionTooLarge returned when the session can not be stored in cookie
+var ErrSessionTooLarge = errors.New("session too large for cookie")
+
+// CookieSessionStore stores the session in the cookie signed with HMAC-SHA256
+// The values are readable by client, do not store secrets in it
+// The first key signs and all keys verify, so keys can be rotated
+type CookieSessionStore struct {
+	signer *internal.Signer
+	// now is used to get current time, replaceable in test
+	now func() time.Time
+}
+
+// NewCookieSessionStore constructor for CookieSessionStore
+func NewCookieSessionStore(keys ...[]byte) *CookieSessionStore {
+	return &CookieSessionStore{signer: internal.NewSigner(keys...), now: time.Now}
+}
+
+// Load implements SessionStore
+func (s *CookieSessionStore) Load(value string) (*Session, error) {
+	entry, ok := s.signer.Verify(cookieSessionName, value)
+	if !ok {
+		return nil, nil
+	}
+	session, err := decodeSessionEntry(entry, s.now())
+	if err != nil {
+		// the signed entry may be encoded by an older version, start a new session
+		return nil, nil
+	}
+	return session, nil
+}
+
+// Save implements SessionStore
+func (s *CookieSessionStore) Save(session *Session, expire time.Time) (string, error) {
+	entry, err := encodeSessionEntry(session, expire)
+	if err != nil {
+		return "", err
+	}
+	value := s.signer.Sign(cookieSessionName, entry)
+	if len(value) > maxCookieSessionSize {
+		return "", ErrSessionTooLarge
+	}
+	return value, nil
+}
+
+// Delete implements SessionStore, nothing to delete since the session is in the cookie
+func (s *CookieSessionStore) Delete(string) error {
+	return nil
+}
+
+// FileSessionStore stores each session in a file of directory
+type FileSessionStore struct {
+	dir string
+	// now is used to get current time, replaceable in test
+	now func() time.Time
+}
+
+// NewFileSessionStore constructor for FileSessionStore
+// The directory will be created if not exist
+func NewFileSessionStore(dir string) (*FileSessionStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &FileSessionStore{dir: dir, now: time.Now}, nil
+}
+
+// Load implements SessionStore
+func (f *FileSessionStore) Load(value string) (*Session, error) {
+	name, ok := f.filename(value)
+	if !ok {
+		return nil, nil
+	}
+	entry, err := os.ReadFile(name)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	session, err := decodeSessionEntry(entry, f.now())
+	if err == nil && session == nil {
+		err = f.Delete(value)
+	}
+	return session, err
+}
+
+// Save implements SessionStore
+// The file is replaced atomically, so concurrent requests never read a partial session
+func (f *FileSessionStore) Save(session *Session, expire time.Time) (string, error) {
+	name, ok := f.filename(session.id)
+	if !ok {
+		return "", errors.New("invalid session id")
+	}
+	entry, err := encodeSessionEntry(session, expire)
+	if err != nil {
+		return "", err
+	}
+	file, err := os.CreateTemp(f.dir, ".tmp-*")
+	if err != nil {
+		return "", err
+	}
+	_, err = file.Write(entry)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), name)
+	}
+	if err != nil {
+		_ = os.Remove(file.Name())
+		return "", err
+	}
+	return session.id, nil
+}
+
+// Delete implements SessionStore
+func (f *FileSessionStore) Delete(id string) error {
+	name, ok := f.filename(id)
+	if !ok {
+		return nil
+	}
+	if err := os.Remove(name); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Cleanup removes the expired sessions, run it periodically
+func (f *FileSessionStore) Cleanup() error {
+	entries, err := os.ReadDir(f.dir)
+	if err != nil {
+		return err
+	}
+	now := uint64(f.now().UnixNano())
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), sessionFileExt) {
+			continue
+		}
+		name := filepath.Join(f.dir, entry.Name())
+		data, err := os.ReadFile(name)
+		if err != nil {
+			continue
+		}
+		if len(data) < 8 || binary.BigEndian.Uint64(data) <= now {
+			if err = os.Remove(name); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// filename returns the file of session id, false if the id is not generated by Session
+func (f *FileSessionStore) filename(id string) (string, bool) {
+	if decoded, err := base64.RawURLEncoding.DecodeString(id); err != nil || len(decoded) != sessionIDLength {
+		return "", false
+	}
+	return filepath.Join(f.dir, id+sessionFileExt), true
+}
@@ -0,0 +1,217 @@
+package regia
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sessionClient sends requests with the cookie of last response
+type sessionClient struct {
+	engine *Engine
+	cookie *http.Cookie
+}
+
+func (s *sessionClient) get(t *testing.T, path string) *httptest.ResponseRecorder {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, path, nil)
+	if s.cookie != nil {
+		request.AddCookie(s.cookie)
+	}
+	s.engine.ServeHTTP(recorder, request)
+	for _, cookie := range recorder.Result().Cookies() {
+		if cookie.Name == defaultSessionCookie {
+			s.cookie = cookie
+			if cookie.MaxAge < 0 {
+				s.cookie = nil
+			}
+		}
+	}
+	return recorder
+}
+
+func newSessionEngine(t *testing.T, config SessionConfig) *Engine {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "flash.html"),
+		[]byte(`{{ range flashes }}{{ .Kind }}:{{ .Message }}{{ end }}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	loader := &TemplateLoader{}
+	if err := loader.ParseGlob(filepath.Join(dir, "*.html")); err != nil {
+		t.Fatal(err)
+	}
+	engine := New()
+	engine.SetHTMLLoader(loader)
+	engine.AddInterceptors(Sessions(config))
+	engine.GET("/anonymous", func(c *Context) { _ = c.String("anonymous") })
+	engine.GET("/login", func(c *Context) {
+		session := GetSession(c)
+		if err := session.RenewID(); err != nil {
+			c.AbortWithError(err)
+			return
+		}
+		session.Set("user", "regia")
+		session.AddFlash("info", "welcome")
+		c.SetStatus(http.StatusFound)
+	})
+	engine.GET("/flash", func(c *Context) { _ = c.HTML("flash.html", nil) })
+	engine.GET("/user", func(c *Context) {
+		user, _ := GetSession(c).Get("user")
+		name, _ := user.(string)
+		_ = c.String(name)
+	})
+	engine.GET("/logout", func(c *Context) { GetSession(c).Destroy() })
+	_ = engine.init()
+	return engine
+}
+
+func TestSessions(t *testing.T) {
+	fileStore, err := NewFileSessionStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	stores := map[string]SessionStore{
+		"memory": NewMemorySessionStore(),
+		"cookie": NewCookieSessionStore([]byte("new key"), []byte("old key")),
+		"file":   fileStore,
+	}
+	for name, store := range stores {
+		client := &sessionClient{engine: newSessionEngine(t, SessionConfig{Store: store})}
+
+		client.get(t, "/anonymous")
+		if client.cookie != nil {
+			t.Errorf("%s: untouched session should not be saved", name)
+		}
+
+		client.get(t, "/login")
+		if client.cookie == nil || !client.cookie.HttpOnly || client.cookie.SameSite != http.SameSiteLaxMode {
+			t.Fatalf("%s: unexpected cookie %v", name, client.cookie)
+		}
+		first := client.cookie.Value
+
+		if body := client.get(t, "/flash").Body.String(); body != "info:welcome" {
+			t.Errorf("%s: expected flash, got %q", name, body)
+		}
+		if body := client.get(t, "/flash").Body.String(); body != "" {
+			t.Errorf("%s: flash should be shown once, got %q", name, body)
+		}
+		if body := client.get(t, "/user").Body.String(); body != "regia" {
+			t.Errorf("%s: expected user regia, got %q", name, body)
+		}
+
+		// login again renews the id, the old session can not be used
+		client.get(t, "/login")
+		if name != "cookie" {
+			if client.cookie.Value == first {
+				t.Errorf("%s: session id should be renewed", name)
+			}
+			if session, _ := store.Load(first); session != nil {
+				t.Errorf("%s: old session should be deleted", name)
+			}
+		}
+
+		client.get(t, "/logout")
+		if client.cookie != nil {
+			t.Errorf("%s: cookie should be deleted", name)
+		}
+		if body := client.get(t, "/user").Body.String(); body != "" {
+			t.Errorf("%s: session should be destroyed, got %q", name, body)
+		}
+	}
+}
+
+func TestSessionsExpire(t *testing.T) {
+	store := NewCookieSessionStore([]byte("key"))
+	client := &sessionClient{engine: newSessionEngine(t, SessionConfig{Store: store, IdleTimeout: 20 * time.Millisecond})}
+	client.get(t, "/login")
+	if body := client.get(t, "/user").Body.String(); body != "regia" {
+		t.Fatalf("expected user regia, got %q", body)
+	}
+	time.Sleep(30 * time.Millisecond)
+	if body := client.get(t, "/user").Body.String(); body != "" {
+		t.Errorf("idle session should be expired, got %q", body)
+	}
+
+	client = &sessionClient{engine: newSessionEngine(t, SessionConfig{Store: store, AbsoluteTimeout: 50 * time.Millisecond})}
+	client.get(t, "/login")
+	for i := 0; i < 3; i++ {
+		time.Sleep(20 * time.Millisecond)
+		client.get(t, "/user")
+	}
+	if body := client.get(t, "/user").Body.String(); body != "" {
+		t.Errorf("session should be expired after absolute timeout, got %q", body)
+	}
+
+	// tampered cookie starts a new session
+	client.get(t, "/login")
+	client.cookie.Value = "x" + client.cookie.Value
+	if body := client.get(t, "/user").Body.String(); body != "" {
+		t.Errorf("tampered session should be ignored, got %q", body)
+	}
+}
+
+func TestSessionsTooLarge(t *testing.T) {
+	var errs []error
+	engine := New()
+	engine.ErrorHandle = func(context *Context, err error) {
+		errs = append(errs, err)
+		HandleError(context, err)
+	}
+	engine.AddInterceptors(Sessions(SessionConfig{Store: NewCookieSessionStore([]byte("key"))}))
+	engine.GET("/write", func(c *Context) {
+		GetSession(c).Set("data", strings.Repeat("x", 10<<10))
+		_ = c.String("ok")
+	})
+	engine.GET("/status", func(c *Context) {
+		GetSession(c).Set("data", strings.Repeat("x", 10<<10))
+		c.SetStatus(http.StatusNoContent)
+	})
+	_ = engine.init()
+
+	for _, path := range []string{"/write", "/status"} {
+		errs = nil
+		recorder := httptest.NewRecorder()
+		engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+		if recorder.Code != http.StatusInternalServerError || strings.Contains(recorder.Body.String(), "ok") {
+			t.Errorf("%s: expected 500, got %d %q", path, recorder.Code, recorder.Body.String())
+		}
+		if len(recorder.Result().Cookies()) != 0 {
+			t.Errorf("%s: unexpected cookies %v", path, recorder.Result().Cookies())
+		}
+		if len(errs) != 1 || !errors.Is(errs[0], ErrSessionTooLarge) {
+			t.Errorf("%s: expected ErrSessionTooLarge, got %v", path, errs)
+		}
+	}
+}
+
+func TestSessionNotLeaked(t *testing.T) {
+	engine := New()
+	sessions := NewBluePrint()
+	sessions.Use(Sessions(SessionConfig{}))
+	sessions.GET("/", func(c *Context) { GetSession(c).Set("user", "regia") })
+	engine.Include("/sessions", sessions)
+	engine.GET("/plain", func(c *Context) {
+		if GetSession(c) != nil {
+			c.SetStatus(http.StatusInternalServerError)
+		}
+	})
+	_ = engine.init()
+
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/sessions/", nil))
+	if len(recorder.Result().Cookies()) != 1 {
+		t.Fatalf("expected session cookie, got %v", recorder.Result().Cookies())
+	}
+	// the Context is reused from the pool
+	recorder = httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/plain", nil))
+	if recorder.Code != http.StatusOK {
+		t.Error("session of previous request leaked")
+	}
+}
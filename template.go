@@ -6,8 +6,10 @@ package regia
 
 import (
 	"errors"
+	"fmt"
 	"github.com/eatmoreapple/regia/renders"
 	"html/template"
+	"path/filepath"
 )
 
 type HTMLLoader interface {
@@ -15,8 +17,44 @@ type HTMLLoader interface {
 	ParseGlob(pattern string) error           // ParseGlob templates by pattern
 }
 
+// FuncsHTMLLoader is implemented by HTMLLoader which supports funcs of request
+// such as csrfToken and csrfField added by CSRF
+type FuncsHTMLLoader interface {
+	HTMLLoader
+	// LoadWithFuncs loads a template by name with funcs added
+	LoadWithFuncs(name string, funcs template.FuncMap) (renders.Render, error)
+}
+
+// requestTemplateFuncs are the names of funcs set by Context.SetTemplateFunc
+// Templates using them can be parsed before any request
+var requestTemplateFuncs = map[string]struct{}{}
+
+// registerRequestTemplateFunc adds placeholder of func set by Context.SetTemplateFunc to TemplateLoader
+func registerRequestTemplateFunc(names ...string) {
+	for _, name := range names {
+		requestTemplateFuncs[name] = struct{}{}
+	}
+}
+
+// placeholderFuncs returns funcs which report that they are not set for the request
+func placeholderFuncs() template.FuncMap {
+	funcs := make(template.FuncMap, len(requestTemplateFuncs))
+	for name := range requestTemplateFuncs {
+		name := name
+		funcs[name] = func(...interface{}) (string, error) {
+			return "", fmt.Errorf("template func %s is not set for the request", name)
+		}
+	}
+	return funcs
+}
+
 type TemplateLoader struct {
 	*template.Template
+	// FuncMap is added to templates before parsing
+	FuncMap template.FuncMap
+	// pristine is the parsed templates which are never executed
+	// html/template can not be cloned after executed, so the funcs of request are added to its clone
+	pristine *template.Template
 }
 
 func (h *TemplateLoader) Load(name string) (renders.Render, error) {
@@ -28,8 +66,44 @@ func (h *TemplateLoader) Load(name string) (renders.Render, error) {
 	return render, nil
 }
 
+// LoadWithFuncs implements FuncsHTMLLoader
+func (h *TemplateLoader) LoadWithFuncs(name string, funcs template.FuncMap) (renders.Render, error) {
+	source := h.pristine
+	if source == nil {
+		source = h.Template
+	}
+	if source == nil {
+		return nil, errors.New("template not found")
+	}
+	clone, err := source.Clone()
+	if err != nil {
+		return nil, err
+	}
+	t := clone.Funcs(funcs).Lookup(name)
+	if t == nil {
+		return nil, errors.New("template not found")
+	}
+	return renders.Template{Template: t}, nil
+}
+
 func (h *TemplateLoader) ParseGlob(pattern string) error {
-	var err error
-	h.Template, err = template.ParseGlob(pattern)
-	return err
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("html/template: pattern matches no files: %#q", pattern)
+	}
+	t, err := template.New(filepath.Base(files[0])).
+		Funcs(placeholderFuncs()).
+		Funcs(h.FuncMap).
+		ParseFiles(files...)
+	if err != nil {
+		return err
+	}
+	if h.Template, err = t.Clone(); err != nil {
+		return err
+	}
+	h.pristine = t
+	return nil
 }
@@ -0,0 +1,249 @@
+// Copyright 2022 eatmoreapple.  All rights reserved.
+// Use of this source code is governed by a GPL style
+// license that can be found in the LICENSE file.
+
+package regia
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// ErrRequestTimeout replied when the handlers do not finish in time
+var ErrRequestTimeout = NewHttpError(http.StatusServiceUnavailable, "request timeout")
+
+// TimeoutConfig is the config of Timeout
+type TimeoutConfig struct {
+	Timeout time.Duration
+	// Handle replies to the request after timeout
+	// Default replies 503 by Context.AbortWithError with ErrRequestTimeout
+	Handle func(c *Context)
+}
+
+// Timeout returns a middleware which limits the time of the rest handlers
+func Timeout(timeout time.Duration) HandleFunc {
+	return TimeoutWithConfig(TimeoutConfig{Timeout: timeout})
+}
+
+// TimeoutWithConfig returns a Timeout middleware with config
+// The rest handlers run in another goroutine with a copy of Context,
+// whose request context has the deadline and response is buffered
+// The buffered response is written if the handlers finish in time,
+// otherwise it is discarded and the later writes return http.ErrHandlerTimeout
+// Handlers should stop their work after the request context done
+// Since the response is buffered, streaming with http.Flusher does not work
+// After timeout the Context is escaped, since the handlers still use the state of it,
+// and temporary files of upload files are removed when the handlers return
+func TimeoutWithConfig(config TimeoutConfig) HandleFunc {
+	if config.Timeout <= 0 {
+		panic("timeout must be positive")
+	}
+	handle := config.Handle
+	if handle == nil {
+		handle = func(c *Context) { c.AbortWithError(ErrRequestTimeout) }
+	}
+	return func(c *Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), config.Timeout)
+		defer cancel()
+
+		writer := &timeoutWriter{ctx: ctx, header: c.ResponseWriter.Header().Clone()}
+		copied := c.copyWith(ctx, writer)
+
+		done := make(chan struct{})
+		panicked := make(chan interface{}, 1)
+		var (
+			lock     sync.Mutex
+			finished bool
+			escaped  bool
+		)
+		go func() {
+			defer func() {
+				lock.Lock()
+				finished = true
+				cleanup := escaped
+				lock.Unlock()
+				// the middleware has returned, clean up for it
+				if cleanup && copied.Request.MultipartForm != nil {
+					_ = copied.Request.MultipartForm.RemoveAll()
+				}
+			}()
+			defer func() {
+				if rec := recover(); rec != nil {
+					if rec != http.ErrAbortHandler {
+						if _, ok := rec.(*PanicError); !ok {
+							rec = &PanicError{Value: rec, Stack: debug.Stack()}
+						}
+					}
+					panicked <- rec
+				}
+			}()
+			copied.Next()
+			close(done)
+		}()
+
+		select {
+		case rec := <-panicked:
+			// the handlers have returned, let Context.finish remove the upload files
+			c.Request.MultipartForm = copied.Request.MultipartForm
+			// let the outer Recovery handle it
+			panic(rec)
+		case <-done:
+			c.Request.MultipartForm = copied.Request.MultipartForm
+			if !writer.timedOut() {
+				c.mergeFrom(copied, writer)
+				return
+			}
+		case <-ctx.Done():
+		}
+		writer.timeout()
+		lock.Lock()
+		if !finished {
+			// the handlers are still running, do not reuse the Context or remove the upload files
+			escaped = true
+			c.Escape()
+		}
+		lock.Unlock()
+		// skip the rest handlers which are running in another goroutine
+		c.Abort()
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			handle(c)
+		}
+	}
+}
+
+// copyWith returns a copy of Context to run the rest handlers
+// The copy has its own request with ctx and writes to writer
+func (c *Context) copyWith(ctx context.Context, writer http.ResponseWriter) *Context {
+	copied := &Context{
+		matched:        c.matched,
+		index:          c.index,
+		abortIndex:     c.abortIndex,
+		status:         c.status,
+		written:        c.written,
+		queryCache:     c.queryCache,
+		formCache:      c.formCache,
+		bodyPrepared:   c.bodyPrepared,
+		bodyErr:        c.bodyErr,
+		body:           c.body,
+		bodyCache:      c.bodyCache,
+		bodyCached:     c.bodyCached,
+		presence:       c.presence,
+		engine:         c.engine,
+		group:          c.group,
+		params:         c.params,
+		fullPath:       c.fullPath,
+		ResponseWriter: writer,
+	}
+	c.lock.RLock()
+	if c.items != nil {
+		copied.items = make(map[string]interface{}, len(c.items))
+		for key, value := range c.items {
+			copied.items[key] = value
+		}
+	}
+	c.lock.RUnlock()
+	for name, fn := range c.templateFuncs {
+		copied.SetTemplateFunc(name, fn)
+	}
+	// the Context stored in request context should be the copy
+	if ctx.Value(contextExist) != nil {
+		ctx = context.WithValue(ctx, ContextKey, copied)
+	}
+	copied.Request = c.Request.WithContext(ctx)
+	return copied
+}
+
+// mergeFrom writes the buffered response and takes the state of copied Context
+func (c *Context) mergeFrom(copied *Context, writer *timeoutWriter) {
+	header := c.ResponseWriter.Header()
+	for key := range header {
+		if _, ok := writer.header[key]; !ok {
+			header.Del(key)
+		}
+	}
+	for key, values := range writer.header {
+		header[key] = values
+	}
+	c.index = copied.index
+	c.abortIndex = copied.abortIndex
+	c.status = copied.status
+	c.written = copied.written
+	if writer.wroteHeader {
+		c.ResponseWriter.WriteHeader(writer.code)
+		c.written = true
+	}
+	if writer.buf.Len() > 0 {
+		_, _ = c.ResponseWriter.Write(writer.buf.Bytes())
+	}
+}
+
+// timeoutWriter buffers the response until the handlers finish
+type timeoutWriter struct {
+	// ctx is checked on writing, handlers may see it done before the middleware does
+	ctx         context.Context
+	header      http.Header
+	buf         bytes.Buffer
+	lock        sync.Mutex
+	code        int
+	wroteHeader bool
+	expired     bool
+}
+
+func (t *timeoutWriter) Header() http.Header {
+	return t.header
+}
+
+func (t *timeoutWriter) Write(b []byte) (int, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.expire() {
+		return 0, http.ErrHandlerTimeout
+	}
+	if !t.wroteHeader {
+		t.writeHeader(http.StatusOK)
+	}
+	return t.buf.Write(b)
+}
+
+func (t *timeoutWriter) WriteHeader(code int) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.expire() || t.wroteHeader {
+		return
+	}
+	t.writeHeader(code)
+}
+
+func (t *timeoutWriter) writeHeader(code int) {
+	t.code = code
+	t.wroteHeader = true
+}
+
+// expire discards the buffered response if ctx done, the lock must be held
+func (t *timeoutWriter) expire() bool {
+	if !t.expired && t.ctx.Err() != nil {
+		t.expired = true
+		t.buf.Reset()
+	}
+	return t.expired
+}
+
+// timedOut reports whether the writes have been rejected
+func (t *timeoutWriter) timedOut() bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.expired
+}
+
+// timeout discards the buffered response
+func (t *timeoutWriter) timeout() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.expired = true
+	t.buf.Reset()
+}
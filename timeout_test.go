@@ -0,0 +1,177 @@
+package regia
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimeout(t *testing.T) {
+	var logs bytes.Buffer
+	late := make(chan error, 1)
+	engine := New()
+	engine.AddInterceptors(RecoveryWithConfig(RecoveryConfig{Writer: &logs}), Timeout(50*time.Millisecond))
+	engine.GET("/fast", func(c *Context) {
+		c.SetHeader("X-Fast", "1")
+		c.SetStatus(http.StatusCreated)
+		_ = c.String("fast")
+	})
+	engine.GET("/slow", func(c *Context) {
+		<-c.Request.Context().Done()
+		_, err := c.ResponseWriter.Write([]byte("late"))
+		late <- err
+	})
+	engine.GET("/panic", func(c *Context) { panic("boom") })
+	_ = engine.init()
+
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/fast", nil))
+	if recorder.Code != http.StatusCreated || recorder.Body.String() != "fast" || recorder.Header().Get("X-Fast") != "1" {
+		t.Errorf("got %d %q %v", recorder.Code, recorder.Body.String(), recorder.Header())
+	}
+
+	recorder = httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/slow", nil))
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503, got %d", recorder.Code)
+	}
+	if err := <-late; err != http.ErrHandlerTimeout {
+		t.Errorf("expected http.ErrHandlerTimeout, got %v", err)
+	}
+	if strings.Contains(recorder.Body.String(), "late") {
+		t.Errorf("late write should be discarded, got %q", recorder.Body.String())
+	}
+
+	recorder = httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	if recorder.Code != http.StatusInternalServerError || !strings.Contains(logs.String(), "boom") {
+		t.Errorf("got %d, log %q", recorder.Code, logs.String())
+	}
+}
+
+func TestTimeoutHandle(t *testing.T) {
+	engine := New()
+	engine.AddInterceptors(TimeoutWithConfig(TimeoutConfig{
+		Timeout: 10 * time.Millisecond,
+		Handle: func(c *Context) {
+			c.SetStatus(http.StatusGatewayTimeout)
+			_ = c.String("gateway timeout")
+		},
+	}))
+	engine.GET("/slow", func(c *Context) { <-c.Request.Context().Done() })
+	_ = engine.init()
+
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/slow", nil))
+	if recorder.Code != http.StatusGatewayTimeout || recorder.Body.String() != "gateway timeout" {
+		t.Errorf("got %d %q", recorder.Code, recorder.Body.String())
+	}
+}
+
+func TestTimeoutBodyLimit(t *testing.T) {
+	engine := New()
+	engine.SetMaxBodySize(16)
+	engine.AddInterceptors(func(c *Context) {
+		// the body is prepared before Timeout
+		var query struct{}
+		if err := c.BindQuery(&query); err != nil {
+			c.AbortWithError(err)
+			return
+		}
+		c.Next()
+	}, Timeout(time.Second))
+	engine.POST("/", func(c *Context) {
+		var v map[string]string
+		if err := c.BindYAML(&v); err != nil {
+			c.AbortWithError(err)
+		}
+	})
+	_ = engine.init()
+
+	request := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("name: "+strings.Repeat("a", 64)))
+	// unknown length, the limit is found while decoding
+	request.ContentLength = -1
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected 413, got %d %s", recorder.Code, recorder.Body.String())
+	}
+}
+
+func TestTimeoutUploadFiles(t *testing.T) {
+	type result struct {
+		name string
+		data string
+		err  error
+	}
+	late := make(chan result, 1)
+	var escaped *Context
+	engine := New()
+	engine.AddInterceptors(func(c *Context) {
+		escaped = c
+		c.Next()
+	}, Timeout(10*time.Millisecond))
+	engine.POST("/upload", func(c *Context) {
+		// store the upload file on disk
+		if err := c.Request.ParseMultipartForm(0); err != nil {
+			late <- result{err: err}
+			return
+		}
+		header := c.Request.MultipartForm.File["file"][0]
+		<-c.Request.Context().Done()
+		// wait for the middleware returning
+		time.Sleep(20 * time.Millisecond)
+		file, err := header.Open()
+		if err != nil {
+			late <- result{err: err}
+			return
+		}
+		defer file.Close()
+		data, err := io.ReadAll(file)
+		name := ""
+		if f, ok := file.(*os.File); ok {
+			name = f.Name()
+		}
+		late <- result{name: name, data: string(data), err: err}
+	})
+	_ = engine.init()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, _ := writer.CreateFormFile("file", "a.txt")
+	_, _ = part.Write([]byte("upload"))
+	_ = writer.Close()
+	request := httptest.NewRequest(http.MethodPost, "/upload", &body)
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503, got %d", recorder.Code)
+	}
+	if !escaped.IsEscape() {
+		t.Error("context should be escaped while the handlers are running")
+	}
+
+	// the upload file is still readable by the late handler
+	res := <-late
+	if res.err != nil || res.data != "upload" || res.name == "" {
+		t.Fatalf("got %q %q %v", res.name, res.data, res.err)
+	}
+	// and removed after the handler returned
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, err := os.Stat(res.name); os.IsNotExist(err) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("temporary file %s is not removed", res.name)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
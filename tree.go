@@ -55,6 +55,8 @@ type routerNode struct {
 	indices   string
 	children  []*routerNode
 	handle    handleFuncNodeGroup
+	// route is the registered path of handle, such as /users/:id
+	route string
 }
 
 // increments priority of the given child and reorders if necessary
@@ -85,6 +87,7 @@ func (n *routerNode) incrementChildPrio(pos int) int {
 // Not concurrency-safe!
 func (n *routerNode) addRoute(path string, handle handleFuncNodeGroup) {
 	n.fullPath = path
+	route := path
 	n.priority++
 	numParams := countParams(path)
 
@@ -115,6 +118,7 @@ func (n *routerNode) addRoute(path string, handle handleFuncNodeGroup) {
 					indices:   n.indices,
 					children:  n.children,
 					handle:    n.handle,
+					route:     n.route,
 					priority:  n.priority - 1,
 				}
 
@@ -130,6 +134,7 @@ func (n *routerNode) addRoute(path string, handle handleFuncNodeGroup) {
 				n.indices = string([]byte{n.path[i]})
 				n.path = path[:i]
 				n.handle = nil
+				n.route = ""
 				n.wildChild = false
 			}
 
@@ -200,7 +205,7 @@ func (n *routerNode) addRoute(path string, handle handleFuncNodeGroup) {
 					n.incrementChildPrio(len(n.indices) - 1)
 					n = child
 				}
-				n.insertChild(numParams, path, n.fullPath, handle)
+				n.insertChild(numParams, path, route, handle)
 				return
 
 			} else if i == len(path) { // Make routerNode a (in-path) leaf
@@ -208,11 +213,12 @@ func (n *routerNode) addRoute(path string, handle handleFuncNodeGroup) {
 					panic("a handle is already registered for path '" + n.fullPath + "'")
 				}
 				n.handle = handle
+				n.route = route
 			}
 			return
 		}
 	} else { // Empty tree
-		n.insertChild(numParams, path, n.fullPath, handle)
+		n.insertChild(numParams, path, route, handle)
 		n.nType = root
 	}
 }
@@ -321,6 +327,7 @@ func (n *routerNode) insertChild(numParams uint8, path, fullPath string, handle
 				nType:     catchAll,
 				maxParams: 1,
 				handle:    handle,
+				route:     fullPath,
 				priority:  1,
 			}
 			n.children = []*routerNode{child}
@@ -332,6 +339,7 @@ func (n *routerNode) insertChild(numParams uint8, path, fullPath string, handle
 	// insert remaining path part and handle to the leaf
 	n.path = path[offset:]
 	n.handle = handle
+	n.route = fullPath
 }
 
 // Returns the handle registered with the given path (key). The values of
@@ -339,7 +347,8 @@ func (n *routerNode) insertChild(numParams uint8, path, fullPath string, handle
 // If no handle can be found, a TSR (trailing slash redirect) recommendation is
 // made if a handle exists with an extra (without the) trailing slash for the
 // given path.
-func (n *routerNode) getValue(path string) (handle handleFuncNodeGroup, p Params, tsr bool) {
+// The registered path of handle is returned as route.
+func (n *routerNode) getValue(path string) (handle handleFuncNodeGroup, p Params, route string, tsr bool) {
 walk: // outer loop for walking the tree
 	for {
 		if len(path) > len(n.path) {
@@ -399,6 +408,7 @@ walk: // outer loop for walking the tree
 					}
 
 					if handle = n.handle; handle != nil {
+						route = n.route
 						return
 					} else if len(n.children) == 1 {
 						// No handle found. Check if a handle for this path + a
@@ -421,6 +431,7 @@ walk: // outer loop for walking the tree
 					p[i].Value = path
 
 					handle = n.handle
+					route = n.route
 					return
 
 				default:
@@ -431,6 +442,7 @@ walk: // outer loop for walking the tree
 			// We should have reached the routerNode containing the handle.
 			// Check if this routerNode has a handle registered.
 			if handle = n.handle; handle != nil {
+				route = n.route
 				return
 			}
 
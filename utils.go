@@ -6,6 +6,7 @@ package regia
 
 import (
 	"math/rand"
+	"net/http"
 	"strings"
 	"time"
 )
@@ -28,3 +29,19 @@ func getRandomString(length int) string {
 	}
 	return builder.String()
 }
+
+// addVary appends values to Vary header if not exist
+func addVary(header http.Header, values ...string) {
+	exist := make(map[string]struct{})
+	for _, line := range header.Values("Vary") {
+		for _, v := range strings.Split(line, ",") {
+			exist[strings.ToLower(strings.TrimSpace(v))] = struct{}{}
+		}
+	}
+	for _, value := range values {
+		if _, ok := exist[strings.ToLower(value)]; !ok {
+			header.Add("Vary", value)
+			exist[strings.ToLower(value)] = struct{}{}
+		}
+	}
+}
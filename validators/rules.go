@@ -0,0 +1,136 @@
+// Copyright 2022 eatmoreapple.  All rights reserved.
+// Use of this source code is governed by a GPL style
+// license that can be found in the LICENSE file.
+
+package validators
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var builtinRules = map[string]RuleFunc{
+	"min":      ruleMin,
+	"max":      ruleMax,
+	"len":      ruleLen,
+	"oneof":    ruleOneOf,
+	"email":    ruleEmail,
+	"url":      ruleURL,
+	"numeric":  ruleNumeric,
+	"alpha":    ruleAlpha,
+	"alphanum": ruleAlphaNum,
+}
+
+// builtinParamChecks reports whether the param of builtin rule is valid
+// Tags are checked when the struct type is compiled, not when the value is validated
+var builtinParamChecks = map[string]func(param string) bool{
+	"min":   isNumberParam,
+	"max":   isNumberParam,
+	"len":   isNumberParam,
+	"oneof": func(param string) bool { return len(strings.TrimSpace(param)) > 0 },
+}
+
+func isNumberParam(param string) bool {
+	_, err := strconv.ParseFloat(param, 64)
+	return err == nil
+}
+
+// size returns length of string, slice and map, or number itself
+func size(value reflect.Value) (float64, bool) {
+	switch value.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(value.String())), true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(value.Len()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return value.Float(), true
+	}
+	return 0, false
+}
+
+func compare(value reflect.Value, param string, fn func(size, limit float64) bool) bool {
+	// param has been checked by builtinParamChecks
+	limit, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return false
+	}
+	s, ok := size(value)
+	return ok && fn(s, limit)
+}
+
+func ruleMin(value reflect.Value, param string) bool {
+	return compare(value, param, func(size, limit float64) bool { return size >= limit })
+}
+
+func ruleMax(value reflect.Value, param string) bool {
+	return compare(value, param, func(size, limit float64) bool { return size <= limit })
+}
+
+func ruleLen(value reflect.Value, param string) bool {
+	return compare(value, param, func(size, limit float64) bool { return size == limit })
+}
+
+func ruleOneOf(value reflect.Value, param string) bool {
+	s := fmt.Sprint(value.Interface())
+	for _, item := range strings.Fields(param) {
+		if s == item {
+			return true
+		}
+	}
+	return false
+}
+
+func ruleEmail(value reflect.Value, _ string) bool {
+	if value.Kind() != reflect.String {
+		return false
+	}
+	address, err := mail.ParseAddress(value.String())
+	return err == nil && address.Address == value.String()
+}
+
+func ruleURL(value reflect.Value, _ string) bool {
+	if value.Kind() != reflect.String {
+		return false
+	}
+	u, err := url.ParseRequestURI(value.String())
+	return err == nil && len(u.Scheme) > 0 && len(u.Host) > 0
+}
+
+func ruleNumeric(value reflect.Value, _ string) bool {
+	if value.Kind() != reflect.String {
+		_, ok := size(value)
+		return ok
+	}
+	_, err := strconv.ParseFloat(value.String(), 64)
+	return err == nil
+}
+
+func matchRunes(value reflect.Value, fn func(r rune) bool) bool {
+	if value.Kind() != reflect.String {
+		return false
+	}
+	for _, r := range value.String() {
+		if !fn(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func ruleAlpha(value reflect.Value, _ string) bool {
+	return matchRunes(value, unicode.IsLetter)
+}
+
+func ruleAlphaNum(value reflect.Value, _ string) bool {
+	return matchRunes(value, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) })
+}
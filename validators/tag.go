@@ -0,0 +1,326 @@
+// Copyright 2022 eatmoreapple.  All rights reserved.
+// Use of this source code is governed by a GPL style
+// license that can be found in the LICENSE file.
+
+package validators
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	validateTag = "validate"
+	jsonTag     = "json"
+
+	ruleRequired  = "required"
+	ruleOmitEmpty = "omitempty"
+)
+
+// RuleFunc reports whether value is valid with given param of rule
+// Pointer value has been dereferenced before called
+type RuleFunc func(value reflect.Value, param string) bool
+
+// TagValidator validates struct with rules from struct tag, such as
+//
+//	`validate:"required,min=1,max=64,email,oneof=a b"`
+//
+// Nested structs, slices and maps of structs will be validated recursively
+// The path of FieldError comes from json tag
+type TagValidator struct {
+	// TagName is the name of struct tag, default is "validate"
+	TagName string
+
+	rules sync.Map // map[string]RuleFunc
+	cache sync.Map // map[reflect.Type]*structRules
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+type rule struct {
+	name  string
+	param string
+	fn    RuleFunc
+}
+
+type fieldRules struct {
+	index     int
+	name      string
+	embedded  bool
+	required  bool
+	omitEmpty bool
+	rules     []rule
+}
+
+type structRules struct {
+	fields []fieldRules
+	// err is the error of parsing tags
+	err error
+}
+
+// RegisterRule add custom rule to TagValidator
+// It will overwrite the builtin rule with same name
+func (t *TagValidator) RegisterRule(name string, fn RuleFunc) {
+	if fn == nil {
+		panic("rule func can not be nil")
+	}
+	t.rules.Store(name, fn)
+}
+
+// isCustom reports whether the rule is registered by TagValidator.RegisterRule
+func (t *TagValidator) isCustom(name string) bool {
+	_, ok := t.rules.Load(name)
+	return ok
+}
+
+func (t *TagValidator) lookupRule(name string) RuleFunc {
+	if fn, ok := t.rules.Load(name); ok {
+		return fn.(RuleFunc)
+	}
+	return builtinRules[name]
+}
+
+func (t *TagValidator) tagName() string {
+	if len(t.TagName) > 0 {
+		return t.TagName
+	}
+	return validateTag
+}
+
+// Validate implement Validator
+// An error other than ValidationErrors is returned if the tags of v are invalid,
+// such as unknown rule or bad param
+func (t *TagValidator) Validate(v interface{}) error {
+	var errs ValidationErrors
+	if err := t.validateValue("", reflect.ValueOf(v), &errs); err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (t *TagValidator) validateValue(path string, value reflect.Value, errs *ValidationErrors) error {
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+	switch value.Kind() {
+	case reflect.Struct:
+		if value.Type() == timeType {
+			return nil
+		}
+		return t.validateStruct(path, value, errs)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			if err := t.validateValue(path+"["+strconv.Itoa(i)+"]", value.Index(i), errs); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		iter := value.MapRange()
+		for iter.Next() {
+			if err := t.validateValue(joinPath(path, fmt.Sprint(iter.Key().Interface())), iter.Value(), errs); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (t *TagValidator) validateStruct(path string, value reflect.Value, errs *ValidationErrors) error {
+	rules := t.compile(value.Type())
+	if rules.err != nil {
+		return rules.err
+	}
+	for _, field := range rules.fields {
+		fieldValue := value.Field(field.index)
+		if field.embedded {
+			if err := t.validateValue(path, fieldValue, errs); err != nil {
+				return err
+			}
+			continue
+		}
+		fieldPath := joinPath(path, field.name)
+		if isEmpty(fieldValue) {
+			if field.required {
+				*errs = append(*errs, newFieldError(fieldPath, ruleRequired, "", fieldValue))
+				continue
+			}
+			if field.omitEmpty {
+				continue
+			}
+		}
+		if failed := checkRules(fieldPath, fieldValue, field.rules); failed != nil {
+			*errs = append(*errs, failed)
+			continue
+		}
+		if err := t.validateValue(fieldPath, fieldValue, errs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkRules(path string, value reflect.Value, rules []rule) *FieldError {
+	if len(rules) == 0 {
+		return nil
+	}
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+	for _, r := range rules {
+		if !r.fn(value, r.param) {
+			return newFieldError(path, r.name, r.param, value)
+		}
+	}
+	return nil
+}
+
+// compile parses the rules of given struct type and cache it
+func (t *TagValidator) compile(typ reflect.Type) *structRules {
+	if cached, ok := t.cache.Load(typ); ok {
+		return cached.(*structRules)
+	}
+	rules, err := t.parseRules(typ)
+	if err != nil {
+		// not cached, the rule may be registered later
+		return &structRules{err: err}
+	}
+	actual, _ := t.cache.LoadOrStore(typ, rules)
+	return actual.(*structRules)
+}
+
+// parseRules parses the tags of given struct type
+func (t *TagValidator) parseRules(typ reflect.Type) (*structRules, error) {
+	rules := &structRules{}
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+		name, hasName := jsonName(field)
+		if name == "-" {
+			continue
+		}
+		fr := fieldRules{index: i, name: name}
+		if field.Anonymous && !hasName {
+			fr.embedded = true
+		}
+		tag := field.Tag.Get(t.tagName())
+		if len(tag) == 0 && !isNestable(field.Type) {
+			continue
+		}
+		for _, item := range strings.Split(tag, ",") {
+			item = strings.TrimSpace(item)
+			if len(item) == 0 {
+				continue
+			}
+			ruleName, param := item, ""
+			if index := strings.Index(item, "="); index >= 0 {
+				ruleName, param = item[:index], item[index+1:]
+			}
+			switch ruleName {
+			case ruleRequired:
+				fr.required = true
+			case ruleOmitEmpty:
+				fr.omitEmpty = true
+			default:
+				fn := t.lookupRule(ruleName)
+				if fn == nil {
+					return nil, fmt.Errorf("unknown validate rule %q of %s.%s", ruleName, typ, field.Name)
+				}
+				if check, ok := builtinParamChecks[ruleName]; ok && !t.isCustom(ruleName) && !check(param) {
+					return nil, fmt.Errorf("invalid param %q of validate rule %q of %s.%s", param, ruleName, typ, field.Name)
+				}
+				fr.rules = append(fr.rules, rule{name: ruleName, param: param, fn: fn})
+			}
+		}
+		rules.fields = append(rules.fields, fr)
+	}
+	return rules, nil
+}
+
+// jsonName returns the name of field in json
+func jsonName(field reflect.StructField) (string, bool) {
+	tag := field.Tag.Get(jsonTag)
+	if index := strings.Index(tag, ","); index >= 0 {
+		tag = tag[:index]
+	}
+	if len(tag) == 0 {
+		return field.Name, false
+	}
+	return tag, true
+}
+
+// isNestable reports whether the type may contain struct to validate
+func isNestable(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct, reflect.Interface:
+		return true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return isNestable(t.Elem())
+	}
+	return false
+}
+
+func isEmpty(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Slice, reflect.Map, reflect.String, reflect.Array:
+		return value.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return value.IsNil()
+	}
+	return value.IsZero()
+}
+
+func joinPath(path, name string) string {
+	if len(path) == 0 {
+		return name
+	}
+	return path + "." + name
+}
+
+func newFieldError(path, tag, param string, value reflect.Value) *FieldError {
+	var v interface{}
+	if value.IsValid() && value.CanInterface() {
+		v = value.Interface()
+	}
+	return &FieldError{Path: path, Tag: tag, Param: param, Value: v, Message: formatMessage(path, tag, param)}
+}
+
+func formatMessage(path, tag, param string) string {
+	switch tag {
+	case ruleRequired:
+		return path + " is required"
+	case "min":
+		return path + " must be at least " + param
+	case "max":
+		return path + " must be at most " + param
+	case "len":
+		return path + " must be exactly " + param
+	case "oneof":
+		return path + " must be one of [" + param + "]"
+	case "email":
+		return path + " must be a valid email address"
+	case "url":
+		return path + " must be a valid url"
+	}
+	if len(param) > 0 {
+		return path + " failed on " + tag + "=" + param
+	}
+	return path + " failed on " + tag
+}
@@ -0,0 +1,89 @@
+package validators
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type address struct {
+	City string `json:"city" validate:"required"`
+}
+
+type user struct {
+	Name    string            `json:"name" validate:"required,min=2,max=8"`
+	Email   string            `json:"email" validate:"omitempty,email"`
+	Role    string            `json:"role" validate:"oneof=admin guest"`
+	Age     *int              `json:"age" validate:"omitempty,min=18"`
+	Address address           `json:"address"`
+	Items   []address         `json:"items"`
+	Extra   map[string]string `validate:"max=1"`
+}
+
+func TestTagValidator(t *testing.T) {
+	age := 3
+	v := &TagValidator{}
+	err := v.Validate(&user{
+		Email: "bad",
+		Role:  "root",
+		Age:   &age,
+		Items: []address{{City: "a"}, {}},
+		Extra: map[string]string{"a": "", "b": ""},
+	})
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected ValidationErrors, got %v", err)
+	}
+	var paths []string
+	for _, e := range errs {
+		paths = append(paths, e.Path+":"+e.Tag)
+	}
+	want := []string{"name:required", "email:email", "role:oneof", "age:min", "address.city:required", "items[1].city:required", "Extra:max"}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("got %v, want %v", paths, want)
+	}
+
+	if err = v.Validate(&user{Name: "bob", Role: "admin", Address: address{City: "x"}}); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestRegisterRule(t *testing.T) {
+	v := &TagValidator{}
+	v.RegisterRule("even", func(value reflect.Value, _ string) bool { return value.Int()%2 == 0 })
+	type number struct {
+		N int `validate:"even"`
+	}
+	if err := v.Validate(number{N: 1}); err == nil {
+		t.Error("expected error for odd number")
+	}
+	if err := v.Validate(number{N: 2}); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestInvalidTag(t *testing.T) {
+	v := &TagValidator{}
+	type unknown struct {
+		N int `validate:"required,odd"`
+	}
+	type badParam struct {
+		N int `validate:"min=one"`
+	}
+	type nested struct {
+		Items []badParam
+	}
+	for _, value := range []interface{}{unknown{N: 1}, &badParam{}, nested{Items: []badParam{{}}}} {
+		err := v.Validate(value)
+		var errs ValidationErrors
+		if err == nil || errors.As(err, &errs) {
+			t.Errorf("%T: expected tag error, got %v", value, err)
+		}
+	}
+
+	// the rule registered later can be used
+	v.RegisterRule("odd", func(value reflect.Value, _ string) bool { return value.Int()%2 == 1 })
+	if err := v.Validate(unknown{N: 1}); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright 2022 eatmoreapple.  All rights reserved.
+// Use of this source code is governed by a GPL style
+// license that can be found in the LICENSE file.
+
+package validators
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Validator validates the destination after binding
+type Validator interface {
+	// Validate returns ValidationErrors if v is invalid
+	Validate(v interface{}) error
+}
+
+// FieldError describes a failed validation rule of a field
+type FieldError struct {
+	// Path is the json path of the field, such as "items[0].name"
+	Path string `json:"path"`
+	// Tag is the name of the failed rule
+	Tag string `json:"tag"`
+	// Param is the parameter of the failed rule
+	Param   string      `json:"param,omitempty"`
+	Message string      `json:"message"`
+	Value   interface{} `json:"-"`
+}
+
+func (f *FieldError) Error() string {
+	return f.Message
+}
+
+// ValidationErrors is a list of FieldError
+type ValidationErrors []*FieldError
+
+func (v ValidationErrors) Error() string {
+	messages := make([]string, len(v))
+	for i, err := range v {
+		messages[i] = err.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// StatusCode returns 422 which is used to reply the request
+func (v ValidationErrors) StatusCode() int {
+	return http.StatusUnprocessableEntity
+}
+
+// Detail returns the failed fields
+func (v ValidationErrors) Detail() interface{} {
+	return []*FieldError(v)
+}